@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// teamScore returns the score of the whole team, the scores of every
+// player plus the bonus for finished goals.
+func (e *Engine) teamScore() int {
+	score := e.teamBonus
+	for _, p := range e.players {
+		score += p.GetScore()
+	}
+	return score
+}
+
+// stepGoal places a new goal line of bits when it is time and checks the
+// goal that is running. A goal is only met if every player eats at least
+// one of its bits and the whole line is eaten before the time runs out.
+func (e *Engine) stepGoal() {
+	if e.config.GoalTime <= 0 {
+		return
+	}
+	if e.goalLeft == 0 {
+		if e.goalWait > 0 {
+			e.goalWait--
+			return
+		}
+		n := len(e.bits)
+		e.bits = entity.NewRandomBitLine(e.rng, e.bits, e.gameMap, 10, GoalRune, gamemap.SelStyle)
+		if len(e.bits) == n {
+			// No room for the line this time, try again next tick
+			return
+		}
+		for _, b := range e.bits[n:] {
+			b.SetState(entity.BitGoal)
+		}
+		e.goalLeft = ticks(e.config.GoalTime)
+		e.goalEaten = make([]bool, len(e.players))
+		return
+	}
+
+	left := 0
+	for _, b := range e.bits {
+		if b.GetState() == entity.BitGoal {
+			left++
+		}
+	}
+	e.goalLeft--
+	if left > 0 && e.goalLeft > 0 {
+		return
+	}
+	met := left == 0
+	for _, eaten := range e.goalEaten {
+		met = met && eaten
+	}
+	e.endGoal(met)
+}
+
+// endGoal removes what is left of the goal line, gives the team its bonus
+// if the goal was met and waits for the next goal.
+func (e *Engine) endGoal(met bool) {
+	var bits []*entity.Bit
+	for _, b := range e.bits {
+		if b.GetState() != entity.BitGoal {
+			bits = append(bits, b)
+		}
+	}
+	e.bits = bits
+	e.goalLeft = 0
+	e.goalWait = GoalEvery
+
+	ev := Event{Type: EventGoal, Player: -1}
+	if met {
+		ev.Score = GoalBonus
+		e.teamBonus += GoalBonus
+		logger.Infof("Goal met, team score %v", e.teamScore())
+	}
+	e.events = append(e.events, ev)
+}
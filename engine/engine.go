@@ -0,0 +1,347 @@
+// Package engine implements the rules of gosnake without any dependency on a
+// terminal. A frontend creates an Engine, feeds it player Inputs once per tick
+// through Step and draws the state it gets back from Snapshot.
+package engine
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// Config holds the values needed to set up a match.
+type Config struct {
+	Width   int            // Map width
+	Height  int            // Map height
+	Mode    int            // Game mode
+	NumBits int            // Number of random bits on the map at the start
+	Players []PlayerConfig // One entry per player
+	Rand    *rand.Rand     `json:"-"` // Source of all randomness in the match
+	Levels  []*Level       // Level progression, DefaultLevels if empty
+
+	// Time a snake takes to move one cell sideways and up or down. Zero
+	// uses DefaultHorizontalMove and DefaultVerticalMove.
+	HorizontalMove time.Duration
+	VerticalMove   time.Duration
+
+	// Snakes that hit the edge of the map come out of the opposite edge
+	// instead of dying
+	Wrap bool
+
+	// The map has no border. Snakes, bits, moving walls and bite
+	// explosions that leave one edge come back on the opposite edge.
+	OpenBorder bool
+
+	// Lives each snake has in a round, 0 for unlimited. A round ends when
+	// at most one snake has lives left.
+	Lives int
+
+	// Length of a round, 0 for no time limit
+	RoundTime time.Duration
+
+	// Rounds in the match, 0 plays a single round
+	Rounds int
+
+	// The match ends as soon as a snake has won more than half the rounds
+	BestOf bool
+
+	// Points each bit dropped by a dead snake is worth, 0 uses
+	// DefaultDropPoints
+	DropPoints int
+
+	// Points a snake needs to move SpeedUpPercent faster, 0 to keep the
+	// same speed
+	SpeedUp int
+
+	// Time added to the round by eating a time bit. Time bits only appear
+	// if this is set.
+	TimeBonus time.Duration
+
+	// Time taken off the round when a snake crashes. If this is set a
+	// single snake respawns with its score instead of ending the game.
+	CrashPenalty time.Duration
+
+	// Time before the first survival hazard, 0 to change levels by score.
+	// If this is set the first level is never left, a new hazard is added
+	// to it at every stage instead and snakes score a point per second.
+	Survival time.Duration
+
+	// Lives shared by all players, 0 to play on your own. If this is set
+	// the players are one team with a shared score. Snakes respawn with
+	// their score and the match ends when the team runs out of lives.
+	TeamLives int
+
+	// Snakes can move through each other
+	PassThrough bool
+
+	// Time the team has to eat a goal line of bits, 0 for no goals
+	GoalTime time.Duration
+
+	// Snakes only move when they are given a direction, one cell for
+	// every direction they can take
+	StepMoves bool
+
+	// Moves the snakes can make in a round, 0 for no limit. The round is
+	// lost when they run out.
+	MoveLimit int
+
+	// Segments a snake starts with. If this is set snakes never grow.
+	Length int
+
+	// Snakes never shrink their tail and every cell they move through
+	// becomes a wall for the rest of the round
+	Trails bool
+}
+
+// PlayerConfig describes how a player looks.
+type PlayerConfig struct {
+	Name    string
+	FGColor string
+	BGColor string
+	Char    rune
+}
+
+// Input is a single player action to be applied on the next tick.
+type Input struct {
+	Player int
+	Action int
+}
+
+// Stats counts what a player has done during a match.
+type Stats struct {
+	Lives      int // Lives left in the current round
+	RoundKills int // Snakes killed in the current round
+	Kills      int // Snakes killed in the match
+	Deaths     int // Times died in the match
+	Wins       int // Rounds won
+}
+
+// Event reports something that happened during a tick that a frontend
+// may want to react to.
+type Event struct {
+	Type   int
+	Player int
+	Name   string
+	Score  int
+	Level  int
+}
+
+// Snapshot is a view of the match state after a tick. The objects it points
+// to belong to the Engine and must not be modified.
+type Snapshot struct {
+	Tick     int
+	Level    int
+	Mode     int
+	Over     bool
+	Map      *gamemap.GameMap
+	BiteMap  *gamemap.GameMap
+	Players  []*entity.Player
+	Entities []*entity.Entity
+	Bits     []*entity.Bit
+	Bites    []*entity.Bit
+	Items    []*entity.Item
+	Stats    []Stats // One entry per player
+	Round    int     // Current round
+	Rounds   int     // Rounds in the match
+	TimeLeft int     // Ticks left in the round, 0 without a time limit
+	Elapsed  int     // Ticks played in the round
+	Moves    int     // Moves made in the round
+	Break    int     // Ticks left in the break after a round
+	Winner   int     // Winner of the last round, -1 if there is none yet
+
+	TeamScore int    // Shared score of the team
+	TeamLives int    // Lives the team has left
+	GoalLeft  int    // Ticks left to meet the current goal, 0 if there is none
+	GoalEaten []bool // Players that have eaten a bit of the current goal
+}
+
+// Engine stores the state of a match and applies the game rules to it.
+type Engine struct {
+	config Config
+	rng    *rand.Rand
+
+	// Game structs
+	players  []*entity.Player // All players in game
+	entities []*entity.Entity // All entities currently in game
+	bites    []*entity.Bit    // All bites currently  in game (triangles)
+	bits     []*entity.Bit    // All bits currently in game (square dots)
+	items    []*entity.Item
+	gameMap  *gamemap.GameMap // Game map
+	biteMap  *gamemap.GameMap // Bite map
+	layout   *gamemap.Layout  // Special tiles of the current level layout
+	portals  []gamemap.Point  // One end of each pair of portals placed at random
+
+	// Simulation state
+	tick       int          // Current simulation tick
+	level      int          // Current game level
+	levels     []*Level     // Level progression
+	spawners   []*spawner   // All bit and bite spawners in game
+	walls      []*wall      // Moving walls in game
+	explosions []*explosion // Bites that are currently exploding
+	moveBits   bool         // Whether random bits move around
+	over       bool         // Set when the game has ended
+	events     []Event      // Events that happened during the current tick
+
+	// Rounds
+	stats      []Stats // Match stats of each player
+	round      int     // Current round
+	timeLeft   int     // Ticks left in the round
+	elapsed    int     // Ticks played in the round
+	moves      int     // Moves made in the round
+	breakTicks int     // Ticks left in the break after a round
+	winner     int     // Winner of the last round
+
+	// Survival
+	stage      *Level // Current survival level
+	hazardWait int    // Ticks until the next survival hazard
+
+	// Co-op
+	teamLives int    // Lives the team has left
+	teamBonus int    // Points the team has for finished goals
+	goalLeft  int    // Ticks left to meet the current goal
+	goalWait  int    // Ticks until the next goal
+	goalEaten []bool // Players that have eaten a bit of the current goal
+}
+
+// NewEngine creates a match with a fresh map and the configured players.
+func NewEngine(config Config) *Engine {
+	e := Engine{
+		config: config,
+		rng:    config.Rand,
+	}
+	if e.rng == nil {
+		e.rng = rand.New(rand.NewSource(0))
+	}
+	e.levels = config.Levels
+	if len(e.levels) == 0 {
+		e.levels = DefaultLevels()
+	}
+	if e.config.HorizontalMove <= 0 {
+		e.config.HorizontalMove = DefaultHorizontalMove
+	}
+	if e.config.VerticalMove <= 0 {
+		e.config.VerticalMove = DefaultVerticalMove
+	}
+	if e.config.Rounds <= 0 {
+		e.config.Rounds = 1
+	}
+	if e.config.DropPoints <= 0 {
+		e.config.DropPoints = DefaultDropPoints
+	}
+	e.stats = make([]Stats, len(config.Players))
+	e.teamLives = config.TeamLives
+	e.winner = -1
+	e.startRound()
+	return &e
+}
+
+// startRound sets up a fresh map and players for the next round.
+func (e *Engine) startRound() {
+	e.round++
+	e.timeLeft = ticks(e.config.RoundTime)
+	e.elapsed = 0
+	e.moves = 0
+	e.players, e.entities, e.walls, e.spawners = nil, nil, nil, nil
+	e.bits, e.bites, e.items, e.explosions = nil, nil, nil, nil
+	for i := range e.stats {
+		e.stats[i].Lives = e.config.Lives
+		e.stats[i].RoundKills = 0
+	}
+	e.initMap()
+	e.initPlayers()
+	e.stage = e.levels[0]
+	e.hazardWait = ticks(e.config.Survival)
+	e.goalLeft, e.goalWait = 0, GoalEvery
+}
+
+// initMap generates new maps for the game.
+func (e *Engine) initMap() {
+
+	// Initialize game states
+	e.level = 1
+
+	// Create a game map
+	m := &gamemap.GameMap{
+		Width:  e.config.Width,
+		Height: e.config.Height,
+		Wrap:   e.config.OpenBorder,
+	}
+	m.InitMap()
+	e.gameMap = m
+	e.applyLevel(e.levels[0])
+	logger.Info("Created game map and set to level 1.")
+
+	biteMap := &gamemap.GameMap{
+		Width:  m.Width,
+		Height: m.Height,
+		Wrap:   m.Wrap,
+	}
+	biteMap.InitMap()
+	biteMap.InitMapBoundary(WallRune, FloorRune, gamemap.DefStyle)
+	e.biteMap = biteMap
+}
+
+// initPlayers creates player objects for the game.
+func (e *Engine) initPlayers() {
+	// Create a player for each configured player
+	for i, pc := range e.config.Players {
+		x, y, dir := e.spawnPoint(i)
+		sty := gamemap.ColorStyle(pc.FGColor, pc.BGColor)
+		p := entity.NewPlayer(x, y, 0, dir, pc.Char, pc.Name, sty)
+		e.setLength(p)
+		e.players = append(e.players, p)
+	}
+	for i := 0; i < e.config.NumBits; i++ {
+		e.bits = append(e.bits, e.newBit())
+	}
+	logger.Infof("Initialized game with %v players.", len(e.players))
+}
+
+// Snapshot returns the current state of the match.
+func (e *Engine) Snapshot() *Snapshot {
+	return &Snapshot{
+		Tick:     e.tick,
+		Level:    e.level,
+		Mode:     e.config.Mode,
+		Over:     e.over,
+		Map:      e.gameMap,
+		BiteMap:  e.biteMap,
+		Players:  e.players,
+		Entities: e.entities,
+		Bits:     e.bits,
+		Bites:    e.bites,
+		Items:    e.items,
+		Stats:    e.stats,
+		Round:    e.round,
+		Rounds:   e.config.Rounds,
+		TimeLeft: e.timeLeft,
+		Break:    e.breakTicks,
+		Winner:   e.winner,
+		Elapsed:  e.elapsed,
+		Moves:    e.moves,
+
+		TeamScore: e.teamScore(),
+		TeamLives: e.teamLives,
+		GoalLeft:  e.goalLeft,
+		GoalEaten: e.goalEaten,
+	}
+}
+
+func (e *Engine) GetTick() int {
+	return e.tick
+}
+
+func (e *Engine) GetMoves() int {
+	return e.moves
+}
+
+func (e *Engine) GetLevel() int {
+	return e.level
+}
+
+func (e *Engine) IsOver() bool {
+	return e.over
+}
@@ -0,0 +1,321 @@
+package engine
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// play runs a two player match for the given number of ticks with the same
+// inputs every time.
+func play(seed int64, n int) *Snapshot {
+	e := NewEngine(Config{
+		Width:   80,
+		Height:  30,
+		NumBits: 5,
+		Rand:    rand.New(rand.NewSource(seed)),
+		Players: []PlayerConfig{
+			{Name: "a", FGColor: "white", BGColor: "black", Char: 'a'},
+			{Name: "b", FGColor: "red", BGColor: "black", Char: 'b'},
+		},
+	})
+	for i := 1; i <= n; i++ {
+		var inputs []Input
+		if i%40 == 0 {
+			inputs = append(inputs, Input{Player: i / 40 % 2, Action: i / 80 % 4})
+		}
+		e.Step(inputs)
+	}
+	return e.Snapshot()
+}
+
+func TestStepIsDeterministic(t *testing.T) {
+	a, b := play(7, 2000), play(7, 2000)
+	if a.Tick != 2000 {
+		t.Fatalf("got tick %v, want 2000", a.Tick)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("the same seed and inputs gave different snapshots")
+	}
+}
+
+func TestStepUsesSeed(t *testing.T) {
+	a, b := play(7, 1), play(8, 1)
+	if reflect.DeepEqual(a.Bits, b.Bits) {
+		t.Fatal("different seeds placed the same bits")
+	}
+}
+
+// empty returns the config of a match on an empty 60x24 map with a player
+// for each name.
+func empty(names ...string) Config {
+	c := Config{Width: 60, Height: 24, Levels: []*Level{{Name: "Empty"}}}
+	for _, n := range names {
+		c.Players = append(c.Players, PlayerConfig{Name: n, FGColor: "white", BGColor: "black", Char: rune(n[0])})
+	}
+	return c
+}
+
+// stepUntil steps a match without input until done is true and returns
+// the events of the last tick. It gives up after a minute of play.
+func stepUntil(t *testing.T, e *Engine, done func([]Event) bool) []Event {
+	t.Helper()
+	for i := 0; i < ticks(time.Minute); i++ {
+		if events := e.Step(nil); done(events) {
+			return events
+		}
+	}
+	t.Fatal("gave up waiting after a minute of play")
+	return nil
+}
+
+// turn gives player i a direction and steps until their head moves or
+// they die. It returns the events of the last tick.
+func turn(t *testing.T, e *Engine, i, action int) []Event {
+	t.Helper()
+	p := e.players[i]
+	x, y := p.GetCurPos(0)
+	moved := func([]Event) bool {
+		nx, ny := p.GetCurPos(0)
+		return p.IsDead() || nx != x || ny != y
+	}
+	if events := e.Step([]Input{{Player: i, Action: action}}); moved(events) {
+		return events
+	}
+	return stepUntil(t, e, moved)
+}
+
+// blockAhead puts a wall on the cell in front of player i.
+func blockAhead(e *Engine, i int) {
+	x, y := e.players[i].GetCurPos(0)
+	dx, dy := e.players[i].CheckDirection()
+	x, y = x+dx, y+dy
+	e.gameMap.Objects[x][y] = gamemap.NewObject(x, y, WallRune, gamemap.DefStyle, true)
+}
+
+func hasEvent(events []Event, typ, player int) bool {
+	for _, ev := range events {
+		if ev.Type == typ && ev.Player == player {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTimeAttack(t *testing.T) {
+	c := empty("a")
+	c.RoundTime = time.Minute
+	c.TimeBonus = 5 * time.Second
+	c.CrashPenalty = 10 * time.Second
+	e := NewEngine(c)
+	p := e.players[0]
+
+	// A time bit right in front of the snake adds the bonus
+	x, y := p.GetCurPos(0)
+	e.bits = append(e.bits, entity.NewBit(x-1, y, 10, TimeBitRune, entity.BitTime, entity.DirNone, gamemap.SelStyle))
+	left := e.timeLeft
+	turn(t, e, 0, ActionLeft)
+	if p.GetScore() != 10 {
+		t.Fatalf("got score %v after the time bit, want 10", p.GetScore())
+	}
+	if want := left - e.tick + ticks(c.TimeBonus); e.timeLeft != want {
+		t.Fatalf("got %v ticks left after the time bit, want %v", e.timeLeft, want)
+	}
+
+	// A crash takes the penalty off and the snake comes back with its score
+	blockAhead(e, 0)
+	left, start := e.timeLeft, e.tick
+	if !hasEvent(turn(t, e, 0, ActionLeft), EventDeath, 0) {
+		t.Fatal("snake did not crash into the wall")
+	}
+	if want := left - (e.tick - start) - ticks(c.CrashPenalty); e.timeLeft != want {
+		t.Fatalf("got %v ticks left after the crash, want %v", e.timeLeft, want)
+	}
+	stepUntil(t, e, func([]Event) bool { return !p.IsDead() })
+	if e.over || p.GetScore() != 10 {
+		t.Fatalf("got over %v and score %v after the crash, want a respawn with 10", e.over, p.GetScore())
+	}
+}
+
+func TestSurvivalStages(t *testing.T) {
+	c := empty("a")
+	c.Survival = 2 * time.Second
+	c.StepMoves = true // The snake stays put so only the hazards change
+	e := NewEngine(c)
+
+	wait := ticks(c.Survival)
+	for stage := 2; stage <= 4; stage++ {
+		for i := 0; i < wait-1; i++ {
+			e.Step(nil)
+		}
+		if e.level != stage-1 {
+			t.Fatalf("got stage %v before %v ticks, want %v", e.level, wait, stage-1)
+		}
+		e.Step(nil)
+		if e.level != stage {
+			t.Fatalf("got stage %v after %v ticks, want %v", e.level, wait, stage)
+		}
+		if e.hazardWait >= wait {
+			t.Fatalf("hazard after stage %v comes in %v ticks, want sooner than %v", stage, e.hazardWait, wait)
+		}
+		wait = e.hazardWait
+	}
+
+	l := e.stage
+	if len(l.Bites) != 2 || !l.Bites[1].Random || len(l.MovingWalls) != 1 || !l.MoveBits {
+		t.Fatalf("got %v bite spawners and %v moving walls at stage 4, want 2 and 1", len(l.Bites), len(l.MovingWalls))
+	}
+	if e.levels[0].Bites != nil || e.levels[0].MovingWalls != nil {
+		t.Fatal("the stages changed the first level")
+	}
+	if want := e.tick / ticks(time.Second); e.players[0].GetScore() != want {
+		t.Fatalf("got score %v after %v ticks, want a point a second", e.players[0].GetScore(), e.tick)
+	}
+}
+
+func TestCoopTeamLives(t *testing.T) {
+	c := empty("a", "b")
+	c.TeamLives = 3
+	e := NewEngine(c)
+	a, b := e.players[0], e.players[1]
+	a.AddScore(20)
+	b.AddScore(30)
+	if s := e.Snapshot().TeamScore; s != 50 {
+		t.Fatalf("got team score %v, want 50", s)
+	}
+
+	// Both snakes crash and come back with their scores
+	blockAhead(e, 0)
+	blockAhead(e, 1)
+	stepUntil(t, e, func([]Event) bool { return a.IsDead() && b.IsDead() })
+	if e.teamLives != 1 || len(e.bits) != 0 {
+		t.Fatalf("got %v team lives and %v dropped bits, want 1 and none", e.teamLives, len(e.bits))
+	}
+	stepUntil(t, e, func([]Event) bool { return !a.IsDead() && !b.IsDead() })
+	if e.over || a.GetScore() != 20 || b.GetScore() != 30 {
+		t.Fatalf("got over %v and scores %v and %v after respawning", e.over, a.GetScore(), b.GetScore())
+	}
+
+	// They run into the same walls and the team is out of lives
+	events := stepUntil(t, e, func(events []Event) bool { return hasEvent(events, EventOver, -1) })
+	for _, ev := range events {
+		if ev.Type == EventOver && ev.Score != 50 {
+			t.Fatalf("got team score %v at the end, want 50", ev.Score)
+		}
+	}
+	if e.teamLives != 0 || e.stats[0].Deaths != 2 || e.stats[1].Deaths != 2 {
+		t.Fatalf("got %v team lives and deaths %v and %v", e.teamLives, e.stats[0].Deaths, e.stats[1].Deaths)
+	}
+}
+
+func TestTrails(t *testing.T) {
+	c := empty("a")
+	c.Trails = true
+	e := NewEngine(c)
+	p := e.players[0]
+	x, y := p.GetCurPos(0)
+
+	turn(t, e, 0, ActionLeft)
+	turn(t, e, 0, ActionLeft)
+	if !e.gameMap.Objects[x][y].IsBlocked() || !e.gameMap.Objects[x-1][y].IsBlocked() {
+		t.Fatal("the cells the snake left are not walls")
+	}
+	if p.GetLength() != 3 {
+		t.Fatalf("got length %v after two moves, want 3", p.GetLength())
+	}
+
+	// Going round into its own trail is a crash but no kill
+	turn(t, e, 0, ActionUp)
+	turn(t, e, 0, ActionRight)
+	events := turn(t, e, 0, ActionDown)
+	if !hasEvent(events, EventDeath, 0) || hasEvent(events, EventKill, 0) {
+		t.Fatalf("got events %v running into the trail, want a death without a kill", events)
+	}
+
+	// The trail stays after the snake is gone
+	stepUntil(t, e, func([]Event) bool { return e.over })
+	if !e.gameMap.Objects[x][y].IsBlocked() || len(e.bits) != 0 {
+		t.Fatal("the trail went away with the snake")
+	}
+}
+
+func TestEdges(t *testing.T) {
+	tests := []struct {
+		name       string
+		wrap, open bool
+		x, y, dir  int
+		wx, wy     int // Where the head ends up, -1 to crash
+	}{
+		{"wall", false, false, 1, 12, entity.DirLeft, -1, -1},
+		{"wrap left", true, false, 1, 12, entity.DirLeft, 58, 12},
+		{"wrap up", true, false, 30, 1, entity.DirUp, 30, 22},
+		{"open left", false, true, 0, 12, entity.DirLeft, 59, 12},
+		{"open down", false, true, 30, 23, entity.DirDown, 30, 0},
+	}
+	for _, test := range tests {
+		c := empty("a")
+		c.Wrap, c.OpenBorder = test.wrap, test.open
+		e := NewEngine(c)
+		p := e.players[0]
+		p.Reset(test.x, test.y, test.dir, gamemap.DefStyle)
+		turn(t, e, 0, ActionItem)
+		x, y := p.GetCurPos(0)
+		switch {
+		case test.wx < 0 && !p.IsDead():
+			t.Errorf("%v: moved to %v,%v, want a crash", test.name, x, y)
+		case test.wx >= 0 && p.IsDead():
+			t.Errorf("%v: crashed, want %v,%v", test.name, test.wx, test.wy)
+		case test.wx >= 0 && (x != test.wx || y != test.wy):
+			t.Errorf("%v: moved to %v,%v, want %v,%v", test.name, x, y, test.wx, test.wy)
+		}
+	}
+}
+
+func TestItemsStack(t *testing.T) {
+	c := empty("a")
+	c.StepMoves = true // The snake stays put while it uses its items
+	e := NewEngine(c)
+	p := e.players[0]
+	use := func(effect int) entity.Active {
+		p.AddItem(entity.NewItem(0, 0, effect, 300, entity.Effects[effect].Rune, gamemap.DefStyle))
+		e.Step([]Input{{Player: 0, Action: ActionItem}})
+		for _, a := range p.GetEffects() {
+			if a.Effect == effect {
+				return a
+			}
+		}
+		t.Fatalf("effect %v is not active", entity.Effects[effect].Name)
+		return entity.Active{}
+	}
+
+	first, second := use(entity.WallPass), use(entity.WallPass)
+	if second.Remaining != first.Remaining-1+300 {
+		t.Errorf("got %v ticks of wallpass after %v and 300 more, want them added", second.Remaining, first.Remaining)
+	}
+	first, second = use(entity.Shield), use(entity.Shield)
+	if second.Remaining != first.Remaining {
+		t.Errorf("got %v ticks of shield after using two, want it to start over at %v", second.Remaining, first.Remaining)
+	}
+	for n := 1; n <= 4; n++ {
+		a := use(entity.Multiplier)
+		want := n
+		if want > entity.Effects[entity.Multiplier].Max {
+			want = entity.Effects[entity.Multiplier].Max
+		}
+		if a.Count != want {
+			t.Fatalf("got multiplier count %v after using %v, want %v", a.Count, n, want)
+		}
+	}
+
+	// Each bit is worth its points once more for each multiplier
+	x, y := p.GetCurPos(0)
+	e.bits = append(e.bits, entity.NewBit(x-1, y, 10, BitRune, entity.BitStatic, entity.DirNone, gamemap.DefStyle))
+	turn(t, e, 0, ActionLeft)
+	if p.GetScore() != 40 {
+		t.Fatalf("got score %v for a bit with 3 multipliers, want 40", p.GetScore())
+	}
+}
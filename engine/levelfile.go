@@ -0,0 +1,278 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// LoadLevels reads every level file in dir, ordered by file name, and checks
+// that they fit on a map of the given size. If dir does not exist it is
+// created and filled with the default levels.
+func LoadLevels(dir string, width, height int) ([]*Level, error) {
+	levels, err := loadDir(dir, DefaultLevels)
+	if err != nil {
+		return nil, err
+	}
+	return levels, ValidateLevels(levels, width, height)
+}
+
+// LoadCampaign reads the campaign stages from dir the same way as
+// LoadLevels, creating it with the default campaign if it does not exist.
+func LoadCampaign(dir string, width, height int) ([]*Level, error) {
+	stages, err := loadDir(dir, DefaultCampaign)
+	if err != nil {
+		return nil, err
+	}
+	return stages, ValidateCampaign(stages, width, height)
+}
+
+// loadDir reads every level file in dir ordered by file name. If dir does
+// not exist it is created and filled with the levels from defaults.
+func loadDir(dir string, defaults func() []*Level) ([]*Level, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		levels := defaults()
+		if err := WriteLevels(levels, dir); err != nil {
+			return nil, err
+		}
+		logger.Infof("Created default levels in: %v", dir)
+		return levels, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var levels []*Level
+	for _, file := range files {
+		l, err := ReadLevel(file)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	logger.Infof("Loaded %v levels from: %v", len(levels), dir)
+
+	return levels, nil
+}
+
+// ReadLevel reads a single level file along with its layout file if it has one.
+func ReadLevel(file string) (*Level, error) {
+	byteValue, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	l, err := DecodeLevel(byteValue)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	if l.LayoutFile != "" {
+		l.Layout, err = ReadLayout(filepath.Join(filepath.Dir(file), l.LayoutFile))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+	}
+	return l, nil
+}
+
+// ReadLayout reads a text map layout from file.
+func ReadLayout(file string) ([]string, error) {
+	byteValue, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimRight(strings.Replace(string(byteValue), "\r\n", "\n", -1), "\n")
+	return strings.Split(text, "\n"), nil
+}
+
+// DecodeLevel converts JSON into a Level. Unknown fields are an error so
+// that typos in level files are caught.
+func DecodeLevel(byteValue []byte) (*Level, error) {
+	var l Level
+	dec := json.NewDecoder(bytes.NewReader(byteValue))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// EncodeLevel converts a Level into JSON.
+func EncodeLevel(l *Level) []byte {
+	file, _ := json.MarshalIndent(l, "", " ")
+	return file
+}
+
+// WriteLevels writes each level to its own numbered file in dir.
+func WriteLevels(levels []*Level, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i, l := range levels {
+		file := filepath.Join(dir, fmt.Sprintf("level%02d.json", i+1))
+		if err := WriteLevel(l, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteLevel writes a single level to file.
+func WriteLevel(l *Level, file string) error {
+	return ioutil.WriteFile(file, EncodeLevel(l), 0644)
+}
+
+// ValidateLevels checks that a level progression makes sense and that
+// everything in it fits on a map of the given size.
+func ValidateLevels(levels []*Level, width, height int) error {
+	if len(levels) == 0 {
+		return fmt.Errorf("no levels")
+	}
+	if levels[0].Score != 0 {
+		return fmt.Errorf("level 1 must start at score 0")
+	}
+	for i, l := range levels {
+		if i > 0 && l.Score <= levels[i-1].Score {
+			return fmt.Errorf("level %v: score %v must be higher than level %v", i+1, l.Score, i)
+		}
+		if err := l.Validate(width, height); err != nil {
+			return fmt.Errorf("level %v: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// ValidateCampaign checks that every stage has a unique name and an
+// objective and that everything in it fits on a map of the given size.
+// Progress is saved by stage name.
+func ValidateCampaign(stages []*Level, width, height int) error {
+	if len(stages) == 0 {
+		return fmt.Errorf("no stages")
+	}
+	names := make(map[string]bool)
+	for i, l := range stages {
+		if names[l.Name] {
+			return fmt.Errorf("stage %v: name %q is used more than once", i+1, l.Name)
+		}
+		names[l.Name] = true
+		if l.Objective == nil || *l.Objective == (Objective{}) {
+			return fmt.Errorf("stage %v: no objective", i+1)
+		}
+		if err := l.Validate(width, height); err != nil {
+			return fmt.Errorf("stage %v: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that everything in a level fits on a map of the given size.
+func (l *Level) Validate(width, height int) error {
+	at := func(x, y float64) (int, int) {
+		return l.Pos(x, y, width, height)
+	}
+	inside := func(x, y int) bool {
+		return x > 0 && x < width-1 && y > 0 && y < height-1
+	}
+	if err := gamemap.CheckLayout(l.Layout, width, height); err != nil {
+		return err
+	}
+	for _, r := range l.Walls {
+		if r.W < 1 || r.H < 1 {
+			return fmt.Errorf("wall at %v,%v has no size", r.X, r.Y)
+		}
+		x, y := at(r.X, r.Y)
+		if !inside(x, y) || !inside(x+r.W-1, y+r.H-1) {
+			return fmt.Errorf("wall at %v,%v is outside the map", r.X, r.Y)
+		}
+	}
+	for _, w := range l.MovingWalls {
+		if !inside(at(w.X, w.Y)) {
+			return fmt.Errorf("moving wall at %v,%v is outside the map", w.X, w.Y)
+		}
+		if _, ok := directions[w.Direction]; !ok {
+			return fmt.Errorf("moving wall at %v,%v has unknown direction %q", w.X, w.Y, w.Direction)
+		}
+		if w.Speed < 1 || w.Segments < 0 {
+			return fmt.Errorf("moving wall at %v,%v needs a speed and segments", w.X, w.Y)
+		}
+	}
+	for _, b := range l.Bits {
+		if b.Gen < 1 || b.Max < 1 || b.Every < 1 {
+			return fmt.Errorf("bits need gen, max and every")
+		}
+	}
+	for _, b := range l.Lines {
+		if b.Every < 1 {
+			return fmt.Errorf("lines need every")
+		}
+	}
+	for _, b := range l.Bites {
+		if b.Gen < 1 || b.Max < 1 || b.Every < 1 {
+			return fmt.Errorf("bites need gen, max and every")
+		}
+	}
+	for _, b := range l.BitLines {
+		x, y := at(b.X, b.Y)
+		if !inside(x, y) {
+			return fmt.Errorf("bit line at %v,%v is outside the map", b.X, b.Y)
+		}
+		switch b.Direction {
+		case "right":
+			x += (b.Length - 1) * 2
+		case "down":
+			y += b.Length - 1
+		default:
+			return fmt.Errorf("bit line at %v,%v has unknown direction %q", b.X, b.Y, b.Direction)
+		}
+		if b.Length < 1 || !inside(x, y) {
+			return fmt.Errorf("bit line at %v,%v is outside the map", b.X, b.Y)
+		}
+	}
+	for _, b := range l.StaticBites {
+		if !inside(at(b.X, b.Y)) {
+			return fmt.Errorf("bite at %v,%v is outside the map", b.X, b.Y)
+		}
+		if _, ok := biteDirections[b.Direction]; !ok {
+			return fmt.Errorf("bite at %v,%v has unknown direction %q", b.X, b.Y, b.Direction)
+		}
+	}
+	for _, i := range l.Items {
+		if !inside(at(i.X, i.Y)) {
+			return fmt.Errorf("item at %v,%v is outside the map", i.X, i.Y)
+		}
+		if _, ok := entity.EffectByName(i.Effect); !ok {
+			return fmt.Errorf("item at %v,%v has unknown effect %q", i.X, i.Y, i.Effect)
+		}
+		if i.Duration < 1 {
+			return fmt.Errorf("item at %v,%v needs a duration", i.X, i.Y)
+		}
+	}
+	for _, d := range l.ItemDrops {
+		if d.Gen < 1 || d.Max < 1 || d.Every < 1 {
+			return fmt.Errorf("item drops need gen, max and every")
+		}
+		for _, name := range d.Effects {
+			if _, ok := entity.EffectByName(name); !ok {
+				return fmt.Errorf("item drops have unknown effect %q", name)
+			}
+		}
+	}
+	if l.Portals < 0 || l.PortalMove < 0 {
+		return fmt.Errorf("portals can not be negative")
+	}
+	if o := l.Objective; o != nil && (o.Score < 0 || o.Survive < 0) {
+		return fmt.Errorf("objective can not be negative")
+	}
+	return nil
+}
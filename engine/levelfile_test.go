@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		level Level
+		want  string // Part of the error, "" if the level is valid
+	}{
+		{"empty", Level{}, ""},
+		{"defaults", *DefaultLevels()[5], ""},
+		{"wall", Level{Walls: []Rect{{X: 10, Y: 5, W: 3, H: 2}}}, ""},
+		{"wall from the far edge", Level{Walls: []Rect{{X: -4, Y: -3, W: 3, H: 2}}}, ""},
+		{"wall without size", Level{Walls: []Rect{{X: 10, Y: 5}}}, "no size"},
+		{"wall off the map", Level{Walls: []Rect{{X: 78, Y: 5, W: 3, H: 2}}}, "outside"},
+		{"wall on the border", Level{Walls: []Rect{{X: 0, Y: 5, W: 3, H: 2}}}, "outside"},
+		{"moving wall off the map", Level{MovingWalls: []MovingWall{{X: 10, Y: 30, Direction: "up", Speed: 1}}}, "outside"},
+		{"moving wall direction", Level{MovingWalls: []MovingWall{{X: 10, Y: 5, Direction: "north", Speed: 1}}}, "unknown direction"},
+		{"moving wall speed", Level{MovingWalls: []MovingWall{{X: 10, Y: 5, Direction: "up"}}}, "speed"},
+		{"bit line", Level{BitLines: []BitLine{{X: 10, Y: 5, Direction: "right", Length: 5}}}, ""},
+		{"bit line off the map", Level{BitLines: []BitLine{{X: 72, Y: 5, Direction: "right", Length: 5}}}, "outside"},
+		{"bit line start off the map", Level{BitLines: []BitLine{{X: 10, Y: 40, Direction: "down", Length: 1}}}, "outside"},
+		{"bit line direction", Level{BitLines: []BitLine{{X: 10, Y: 5, Direction: "up", Length: 5}}}, "unknown direction"},
+		{"bite", Level{StaticBites: []StaticBite{{X: 10, Y: 5, Direction: "all"}}}, ""},
+		{"bite off the map", Level{StaticBites: []StaticBite{{X: -1, Y: 5, Direction: "all"}}}, "outside"},
+		{"bite direction", Level{StaticBites: []StaticBite{{X: 10, Y: 5, Direction: "around"}}}, "unknown direction"},
+		{"item", Level{Items: []ItemSpawn{{X: 0.5, Y: 0.5, Effect: "wallpass", Duration: 1000}}}, ""},
+		{"item off the map", Level{Items: []ItemSpawn{{X: 80, Y: 5, Effect: "wallpass", Duration: 1000}}}, "outside"},
+		{"item effect", Level{Items: []ItemSpawn{{X: 10, Y: 5, Effect: "flight", Duration: 1000}}}, "unknown effect"},
+		{"item duration", Level{Items: []ItemSpawn{{X: 10, Y: 5, Effect: "wallpass"}}}, "duration"},
+		{"item drop effect", Level{ItemDrops: []ItemDrop{{Gen: 1, Max: 1, Every: 1000, Effects: []string{"flight"}}}}, "unknown effect"},
+		{"layout too big", Level{Layout: []string{strings.Repeat(".", 81)}}, "layout"},
+		{"layout tile", Level{Layout: []string{"..?.."}}, "unknown tile"},
+		{"negative portals", Level{Portals: -1}, "negative"},
+	}
+	for _, tt := range tests {
+		err := tt.level.Validate(80, 30)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%v: %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%v: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeLevel(t *testing.T) {
+	l, err := DecodeLevel([]byte(`{"name": "a", "score": 20, "walls": [{"x": -0.25, "y": 5, "w": 2, "h": 1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "a" || l.Score != 20 || len(l.Walls) != 1 || l.Walls[0].X != -0.25 {
+		t.Fatalf("decoded %+v", l)
+	}
+	if _, err := DecodeLevel([]byte(`{"name": "a", "wals": []}`)); err == nil {
+		t.Fatal("an unknown field was accepted")
+	}
+	if _, err := DecodeLevel(EncodeLevel(DefaultLevels()[5])); err != nil {
+		t.Fatalf("an encoded level could not be decoded: %v", err)
+	}
+}
+
+func TestValidateLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []int
+		want   string
+	}{
+		{"ordered", []int{0, 10, 20}, ""},
+		{"no levels", nil, "no levels"},
+		{"first above zero", []int{5, 10}, "score 0"},
+		{"same score", []int{0, 10, 10}, "higher"},
+		{"lower score", []int{0, 20, 10}, "higher"},
+	}
+	for _, tt := range tests {
+		var levels []*Level
+		for _, s := range tt.scores {
+			levels = append(levels, &Level{Score: s})
+		}
+		err := ValidateLevels(levels, 80, 30)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%v: %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%v: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if err := ValidateLevels(DefaultLevels(), 60, 24); err != nil {
+		t.Errorf("default levels: %v", err)
+	}
+}
+
+func TestValidateCampaign(t *testing.T) {
+	goal := &Objective{Score: 10}
+	tests := []struct {
+		name   string
+		stages []*Level
+		want   string
+	}{
+		{"valid", []*Level{{Name: "a", Objective: goal}, {Name: "b", Objective: goal}}, ""},
+		{"no stages", nil, "no stages"},
+		{"duplicate name", []*Level{{Name: "a", Objective: goal}, {Name: "a", Objective: goal}}, "more than once"},
+		{"no objective", []*Level{{Name: "a"}}, "no objective"},
+		{"empty objective", []*Level{{Name: "a", Objective: &Objective{}}}, "no objective"},
+		{"bad stage", []*Level{{Name: "a", Objective: goal, Portals: -1}}, "stage 1"},
+	}
+	for _, tt := range tests {
+		err := ValidateCampaign(tt.stages, 80, 30)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%v: %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%v: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if err := ValidateCampaign(DefaultCampaign(), 60, 24); err != nil {
+		t.Errorf("default campaign: %v", err)
+	}
+}
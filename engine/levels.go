@@ -0,0 +1,673 @@
+package engine
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// Level describes everything that is active on the map once a player's score
+// reaches the level's Score. Positions may be negative to count back from the
+// right or bottom edge of the map, and their fraction is a share of the map,
+// so 0.25 is a quarter of the way across. Times are in milliseconds. A
+// Layout is a text map that is placed in the middle of the map, it can be
+// given inline or read from a LayoutFile next to the level file. If
+// FromLayout is set positions are instead counted in cells from the top left
+// corner of the layout, so everything stays in the same place in the layout
+// on maps of any size. FillBits places a bit on every bit tile of the layout
+// when the level starts. Portals is the number of portal pairs placed at
+// random, if PortalMove is set they move to new spots every PortalMove ms.
+type Level struct {
+	Name        string       `json:"name"`
+	Score       int          `json:"score"`
+	Layout      []string     `json:"layout,omitempty"`
+	LayoutFile  string       `json:"layout_file,omitempty"`
+	Walls       []Rect       `json:"walls,omitempty"`
+	MovingWalls []MovingWall `json:"moving_walls,omitempty"`
+	MoveBits    bool         `json:"move_bits,omitempty"`
+	Bits        []BitSpawn   `json:"bits,omitempty"`
+	Lines       []LineSpawn  `json:"lines,omitempty"`
+	Bites       []BiteSpawn  `json:"bites,omitempty"`
+	Items       []ItemSpawn  `json:"items,omitempty"`
+	ItemDrops   []ItemDrop   `json:"item_drops,omitempty"`
+	BitLines    []BitLine    `json:"bit_lines,omitempty"`
+	StaticBites []StaticBite `json:"static_bites,omitempty"`
+	Objective   *Objective   `json:"objective,omitempty"`
+	FillBits    bool         `json:"fill_bits,omitempty"`
+	Portals     int          `json:"portals,omitempty"`
+	PortalMove  int          `json:"portal_move,omitempty"`
+	FromLayout  bool         `json:"from_layout,omitempty"`
+}
+
+// Objective is what has to be done to clear a level. Every part that is set
+// has to be met. Survive is in seconds.
+type Objective struct {
+	Score   int  `json:"score,omitempty"`
+	Survive int  `json:"survive,omitempty"`
+	AllBits bool `json:"all_bits,omitempty"`
+}
+
+// Rect is a block of static wall.
+type Rect struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	W int     `json:"w"`
+	H int     `json:"h"`
+}
+
+// MovingWall is a wall that moves back and forth across the map.
+type MovingWall struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Direction string  `json:"direction"`
+	Speed     int     `json:"speed"`
+	Segments  int     `json:"segments"`
+}
+
+// BitSpawn adds Gen random bits every Every ms while there are fewer than Max.
+type BitSpawn struct {
+	Gen   int `json:"gen"`
+	Max   int `json:"max"`
+	Every int `json:"every"`
+}
+
+// LineSpawn adds a random line of bits every Every ms.
+type LineSpawn struct {
+	Every int `json:"every"`
+}
+
+// BiteSpawn adds Gen bites every Every ms while there are fewer than Max.
+// Random bites explode in a single random direction.
+type BiteSpawn struct {
+	Gen    int  `json:"gen"`
+	Max    int  `json:"max"`
+	Every  int  `json:"every"`
+	Random bool `json:"random,omitempty"`
+}
+
+// ItemSpawn places an item when the level starts.
+type ItemSpawn struct {
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Effect   string  `json:"effect"`
+	Duration int     `json:"duration"`
+}
+
+// ItemDrop adds Gen random items every Every ms while there are fewer than
+// Max. The items have one of the named Effects, or any effect if none are
+// named, and last as long as the effect does by default.
+type ItemDrop struct {
+	Gen     int      `json:"gen"`
+	Max     int      `json:"max"`
+	Every   int      `json:"every"`
+	Effects []string `json:"effects,omitempty"`
+}
+
+// BitLine places a line of Length bits when the level starts. Direction is
+// "right" for a horizontal line or "down" for a vertical one.
+type BitLine struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Direction string  `json:"direction"`
+	Length    int     `json:"length"`
+}
+
+// StaticBite places a bite when the level starts. Direction is the way it
+// explodes, or "all".
+type StaticBite struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Direction string  `json:"direction"`
+}
+
+// spawner runs its spawn function once every set number of ticks. def is the
+// level definition it was created from so it can carry over between levels.
+type spawner struct {
+	def   interface{}
+	every int
+	wait  int
+	spawn func(e *Engine)
+}
+
+// wall is a moving wall entity along with its level definition.
+type wall struct {
+	def MovingWall
+	*entity.Entity
+}
+
+// BasicLevels returns the single open level of classic snake. It only has
+// bits, no walls, bites or items.
+func BasicLevels() []*Level {
+	return []*Level{
+		{
+			Name: "Classic",
+			Bits: []BitSpawn{{Gen: 1, Max: 5, Every: 1000}},
+		},
+	}
+}
+
+// DefaultCampaign returns the built in campaign stages.
+func DefaultCampaign() []*Level {
+	bits := BitSpawn{Gen: 1, Max: 5, Every: 1000}
+	return []*Level{
+		{
+			Name:      "First Steps",
+			Bits:      []BitSpawn{bits},
+			Objective: &Objective{Score: 50},
+		},
+		{
+			Name: "Clean Sweep",
+			BitLines: []BitLine{
+				{X: 10, Y: 5, Direction: "right", Length: 8},
+				{X: -26, Y: -5, Direction: "right", Length: 8},
+				{X: 8, Y: 8, Direction: "down", Length: 8},
+				{X: -8, Y: 8, Direction: "down", Length: 8},
+			},
+			Objective: &Objective{AllBits: true},
+		},
+		{
+			Name:      "Hold On",
+			Bits:      []BitSpawn{bits},
+			Bites:     []BiteSpawn{{Gen: 1, Max: 3, Every: 5000}},
+			Objective: &Objective{Survive: 60},
+		},
+		{
+			Name: "Crossing",
+			MovingWalls: []MovingWall{
+				{X: 16, Y: 0.25, Direction: "left", Speed: 1, Segments: 10},
+				{X: -15, Y: -0.25, Direction: "right", Speed: 1, Segments: 10},
+			},
+			Bits:      []BitSpawn{bits},
+			Bites:     []BiteSpawn{{Gen: 1, Max: 2, Every: 10000}},
+			Objective: &Objective{Score: 100},
+		},
+		{
+			Name: "Gauntlet",
+			MovingWalls: []MovingWall{
+				{X: 16, Y: 6, Direction: "left", Speed: 1, Segments: 12},
+				{X: -15, Y: -6, Direction: "right", Speed: 1, Segments: 12},
+				{X: 0.25, Y: 4, Direction: "down", Speed: 1, Segments: 5},
+				{X: -0.25, Y: -4, Direction: "up", Speed: 1, Segments: 5},
+			},
+			MoveBits:  true,
+			Bits:      []BitSpawn{bits},
+			Bites:     []BiteSpawn{{Gen: 1, Max: 2, Every: 8000}, {Gen: 1, Max: 2, Every: 8000, Random: true}},
+			Objective: &Objective{Survive: 90},
+		},
+	}
+}
+
+// DefaultLevels returns the built in level progression.
+func DefaultLevels() []*Level {
+	bits := BitSpawn{Gen: 2, Max: 10, Every: 3000}
+	lines := LineSpawn{Every: 15000}
+	bites := BiteSpawn{Gen: 1, Max: 3, Every: 20000}
+	randomBites := BiteSpawn{Gen: 1, Max: 3, Every: 20000, Random: true}
+	items := []ItemDrop{{Gen: 1, Max: 2, Every: 20000}}
+	walls := []MovingWall{
+		{X: 16, Y: 0.25, Direction: "left", Speed: 1, Segments: 15},
+		{X: -15, Y: -0.25, Direction: "right", Speed: 1, Segments: 15},
+	}
+	return []*Level{
+		{
+			Name:  "Open",
+			Score: 0,
+			Bits:  []BitSpawn{bits},
+			Lines: []LineSpawn{lines},
+			Items: []ItemSpawn{{X: 3.5, Y: 3.5, Effect: "wallpass", Duration: 3000}},
+		},
+		{
+			Name:      "Wandering Bits",
+			Score:     20,
+			MoveBits:  true,
+			Bits:      []BitSpawn{bits},
+			Lines:     []LineSpawn{lines},
+			ItemDrops: items,
+		},
+		{
+			Name:      "Bites",
+			Score:     40,
+			MoveBits:  true,
+			Bits:      []BitSpawn{bits},
+			Lines:     []LineSpawn{lines},
+			Bites:     []BiteSpawn{bites},
+			ItemDrops: items,
+		},
+		{
+			Name:        "Moving Walls",
+			Score:       60,
+			MovingWalls: walls,
+			MoveBits:    true,
+			Bits:        []BitSpawn{bits},
+			Lines:       []LineSpawn{lines},
+			Bites:       []BiteSpawn{bites},
+			ItemDrops:   items,
+		},
+		{
+			Name:        "Random Bites",
+			Score:       80,
+			MovingWalls: walls,
+			MoveBits:    true,
+			Bits:        []BitSpawn{bits},
+			Lines:       []LineSpawn{lines},
+			Bites:       []BiteSpawn{bites, randomBites},
+			ItemDrops:   items,
+		},
+		{
+			Name:  "Gates",
+			Score: 100,
+			MovingWalls: append(walls,
+				MovingWall{X: 0.25, Y: 6, Direction: "up", Speed: 1, Segments: 7},
+				MovingWall{X: 1.25, Y: 6, Direction: "up", Speed: 1, Segments: 7},
+				MovingWall{X: -0.25, Y: -6, Direction: "down", Speed: 1, Segments: 7},
+				MovingWall{X: -1.25, Y: -6, Direction: "down", Speed: 1, Segments: 7},
+			),
+			MoveBits:   true,
+			Bits:       []BitSpawn{bits},
+			Lines:      []LineSpawn{lines},
+			Bites:      []BiteSpawn{randomBites},
+			Portals:    2,
+			PortalMove: 20000,
+			ItemDrops:  items,
+		},
+	}
+}
+
+// applyLevel sets up the map for a level. Spawners and moving walls that
+// are defined the same way in the new level keep running as they were.
+func (e *Engine) applyLevel(l *Level) {
+	m := e.gameMap
+
+	// Static walls
+	m.InitMapBoundary(WallRune, FloorRune, gamemap.DefStyle)
+	e.layout = nil
+	if len(l.Layout) > 0 {
+		layout, err := m.InitMapLayout(l.Layout, WallRune, FloorRune, gamemap.DefStyle)
+		if err != nil {
+			logger.Errorf("Error placing layout for level %v: %v", l.Name, err)
+		}
+		e.layout = layout
+	}
+	for _, r := range l.Walls {
+		x, y := e.resolve(l, r.X, r.Y)
+		for i := x; i < x+r.W; i++ {
+			for j := y; j < y+r.H; j++ {
+				m.Objects[i][j] = gamemap.NewObject(i, j, WallRune, gamemap.DefStyle, true)
+			}
+		}
+	}
+
+	// Portals
+	e.portals = nil
+	if e.layout != nil {
+		for i, p := range e.layout.Portals {
+			m.AddPortal(p[0], p[1], PortalRune, gamemap.PortalStyle(i))
+		}
+	}
+	for i := 0; i < l.Portals; i++ {
+		e.portals = append(e.portals, e.randomPortal(len(e.portals)))
+	}
+
+	e.moveBits = l.MoveBits
+
+	// Spawners
+	old := e.spawners
+	e.spawners = nil
+	keep := func(def interface{}) bool {
+		for i, s := range old {
+			if s != nil && s.def == def {
+				e.spawners = append(e.spawners, s)
+				old[i] = nil
+				return true
+			}
+		}
+		return false
+	}
+	for _, d := range l.Bits {
+		if !keep(d) {
+			e.spawners = append(e.spawners, randomBits(d))
+		}
+	}
+	for _, d := range l.Lines {
+		if !keep(d) {
+			e.spawners = append(e.spawners, randomLines(d))
+		}
+	}
+	for _, d := range l.Bites {
+		if !keep(d) {
+			e.spawners = append(e.spawners, randomBites(d))
+		}
+	}
+	for _, d := range l.ItemDrops {
+		if !keep(d.key()) {
+			e.spawners = append(e.spawners, randomItems(d))
+		}
+	}
+	if len(e.portals) > 0 && l.PortalMove > 0 {
+		e.spawners = append(e.spawners, movePortals(l.PortalMove))
+	}
+
+	// Moving walls
+	oldWalls := e.walls
+	e.walls = nil
+	e.entities = nil
+	for _, d := range l.MovingWalls {
+		var w *wall
+		for i := range oldWalls {
+			if oldWalls[i] != nil && oldWalls[i].def == d {
+				w = oldWalls[i]
+				oldWalls[i] = nil
+				break
+			}
+		}
+		if w == nil {
+			w = movingWall(e, l, d)
+		}
+		e.walls = append(e.walls, w)
+		e.entities = append(e.entities, w.Entity)
+	}
+
+	// Starting items
+	for _, d := range l.Items {
+		x, y := e.resolve(l, d.X, d.Y)
+		effect, _ := entity.EffectByName(d.Effect)
+		i := entity.NewItem(x, y, effect, ms(d.Duration), entity.Effects[effect].Rune, gamemap.DefStyle)
+		e.items = append(e.items, i)
+	}
+	for _, d := range l.BitLines {
+		x, y := e.resolve(l, d.X, d.Y)
+		if d.Direction == "right" {
+			e.bits = entity.NewBitLineH(e.bits, x-2, y, 10, d.Length, BitRune, gamemap.BitStyle)
+		} else {
+			e.bits = entity.NewBitLineV(e.bits, x, y-1, 10, d.Length, BitRune, gamemap.BitStyle)
+		}
+	}
+	for _, d := range l.StaticBites {
+		x, y := e.resolve(l, d.X, d.Y)
+		dir := biteDirections[d.Direction]
+		b := entity.NewBit(x, y, 50, BiteRunes[dir], entity.BitStatic, dir, gamemap.BiteExplodedStyle)
+		e.bites = append(e.bites, b)
+	}
+	if e.layout != nil && l.FillBits {
+		for _, p := range e.layout.Bits {
+			e.bits = append(e.bits, entity.NewBit(p.X, p.Y, 10, BitRune, entity.BitStatic, entity.DirNone, gamemap.BitStyle))
+		}
+	}
+	if e.layout != nil {
+		for _, p := range e.layout.Items {
+			i := entity.NewItem(p.X, p.Y, entity.WallPass, ms(DefaultItemDuration), ItemRune, gamemap.DefStyle)
+			e.items = append(e.items, i)
+		}
+	}
+}
+
+// newBit creates a bit at a random position. If the current layout has
+// bit tiles the bit is placed on one of them.
+func (e *Engine) newBit() *entity.Bit {
+	if e.layout != nil && len(e.layout.Bits) > 0 {
+		p := e.layout.Bits[e.rng.Intn(len(e.layout.Bits))]
+		return entity.NewBit(p.X, p.Y, 10, BitRune, entity.BitRandom, entity.DirNone, gamemap.BitStyle)
+	}
+	return entity.NewRandomBit(e.rng, e.gameMap, 10, BitRune, gamemap.BitStyle)
+}
+
+// randomFreeCell returns a random cell that is free of walls and portals.
+func (e *Engine) randomFreeCell() (int, int) {
+	return entity.RandomCell(e.rng, e.gameMap)
+}
+
+// spawnPoint returns where a player starts and the direction they start
+// moving in. Players start on the layout's spawn tiles if it has any. A
+// single player otherwise starts in the middle of the map and more players
+// are spread out around it.
+func (e *Engine) spawnPoint(i int) (int, int, int) {
+	dir := entity.DirLeft
+	if len(e.config.Players) > 1 {
+		dir = spawnDirs[i%len(spawnDirs)]
+	}
+	if e.config.StepMoves {
+		// The first move can go any way
+		dir = entity.DirNone
+	}
+	if e.layout != nil && len(e.layout.Spawns) > 0 {
+		p := e.layout.Spawns[i%len(e.layout.Spawns)]
+		return p.X, p.Y, dir
+	}
+	if len(e.config.Players) == 1 {
+		return e.gameMap.Width / 2, e.gameMap.Height / 2, dir
+	}
+	s := spawnSpots[i%len(spawnSpots)]
+	return e.gameMap.Width * s[0] / 4, e.gameMap.Height * s[1] / 4, dir
+}
+
+// randomLines creates a spawner that adds a random line of bits.
+func randomLines(d LineSpawn) *spawner {
+	return &spawner{
+		def:   d,
+		every: ms(d.Every),
+		spawn: func(e *Engine) {
+			e.bits = entity.NewRandomBitLine(e.rng, e.bits, e.gameMap, 10, BitRune, gamemap.BitStyle)
+		},
+	}
+}
+
+// randomBits creates a spawner that adds Gen random bits as long as there
+// are fewer than Max bits on the map.
+func randomBits(d BitSpawn) *spawner {
+	return &spawner{
+		def:   d,
+		every: ms(d.Every),
+		spawn: func(e *Engine) {
+			for i := 0; i < d.Gen; i++ {
+				if len(e.bits)-d.Gen < d.Max {
+					e.bits = append(e.bits, e.newBit())
+				}
+			}
+		},
+	}
+}
+
+// randomBites creates a spawner that adds Gen bites as long as there are
+// fewer than Max bites on the map. If Random is set the bites explode in
+// a random direction instead of all directions.
+func randomBites(d BiteSpawn) *spawner {
+	return &spawner{
+		def:   d,
+		every: ms(d.Every),
+		spawn: func(e *Engine) {
+			for i := 0; i < d.Gen; i++ {
+				if len(e.bites)-d.Gen < d.Max {
+					newB := entity.NewRandomBite(e.rng, e.gameMap, BiteRunes, gamemap.BiteExplodedStyle, d.Random)
+					e.bites = append(e.bites, newB)
+				}
+			}
+		},
+	}
+}
+
+// randomItems creates a spawner that adds Gen random items as long as there
+// are fewer than Max items on the map.
+func randomItems(d ItemDrop) *spawner {
+	return &spawner{
+		def:   d.key(),
+		every: ms(d.Every),
+		spawn: func(e *Engine) {
+			for i := 0; i < d.Gen; i++ {
+				if len(e.items)-d.Gen < d.Max {
+					e.items = append(e.items, e.newItem(d.Effects))
+				}
+			}
+		},
+	}
+}
+
+// key returns a comparable copy of the item drop so its spawner can carry
+// over between levels.
+func (d ItemDrop) key() interface{} {
+	return [4]interface{}{d.Gen, d.Max, d.Every, strings.Join(d.Effects, ",")}
+}
+
+// newItem creates an item with one of the named effects, or any effect,
+// at a random position.
+func (e *Engine) newItem(names []string) *entity.Item {
+	effect := e.rng.Intn(len(entity.Effects))
+	if len(names) > 0 {
+		effect, _ = entity.EffectByName(names[e.rng.Intn(len(names))])
+	}
+	info := entity.Effects[effect]
+	x, y := e.randomFreeCell()
+	return entity.NewItem(x, y, effect, ms(info.Duration), info.Rune, gamemap.DefStyle)
+}
+
+// movePortals creates a spawner that moves the portals placed at random to
+// new random spots.
+func movePortals(every int) *spawner {
+	return &spawner{
+		every: ms(every),
+		wait:  ms(every) - 1,
+		spawn: func(e *Engine) {
+			for i, p := range e.portals {
+				e.gameMap.RemovePortal(p, FloorRune, gamemap.DefStyle)
+				e.portals[i] = e.randomPortal(i)
+			}
+		},
+	}
+}
+
+// randomPortal places the nth pair of random portals on free cells away
+// from the snakes and returns one end of it.
+func (e *Engine) randomPortal(n int) gamemap.Point {
+	var ends [2]gamemap.Point
+	for i := range ends {
+		for try := 0; try < 10; try++ {
+			x, y := e.randomFreeCell()
+			ends[i] = gamemap.Point{X: x, Y: y}
+			if !e.nearPlayer(x, y, PortalDistance) && !e.onBit(x, y) && (i == 0 || ends[1] != ends[0]) {
+				break
+			}
+		}
+	}
+	style := gamemap.PortalStyle(n)
+	if e.layout != nil {
+		style = gamemap.PortalStyle(len(e.layout.Portals) + n)
+	}
+	e.gameMap.AddPortal(ends[0], ends[1], PortalRune, style)
+	return ends[0]
+}
+
+// onBit reports whether there is a bit at x, y.
+func (e *Engine) onBit(x, y int) bool {
+	for _, b := range e.bits {
+		if bx, by := b.GetCurPos(); bx == x && by == y {
+			return true
+		}
+	}
+	return false
+}
+
+// movingWall creates a wall entity that moves back and forth across the map.
+func movingWall(e *Engine, l *Level, d MovingWall) *wall {
+	x, y := e.resolve(l, d.X, d.Y)
+	w := entity.NewEntity(x, y, directions[d.Direction], d.Speed, WallRune, gamemap.DefStyle)
+	w.AddSegment(d.Segments, WallRune, gamemap.DefStyle)
+	return &wall{d, w}
+}
+
+// stepWall moves a wall entity one cell, reversing it when it hits
+// something on the map. Walls go round an open map.
+func stepWall(w *entity.Entity, m *gamemap.GameMap) {
+	x, y := w.GetCurPos(0)
+	dx, dy := w.CheckDirection()
+	dx, dy = m.WrapMove(x, y, dx, dy)
+	if w.IsBlockedByMap(m, dx, dy) {
+		var newPos []*gamemap.Object
+		for i := 0; i < w.GetLength(); i++ {
+			o := w.GetSegment(w.GetLength() - 1 - i)
+			newPos = append(newPos, o)
+		}
+		w.NewPos(newPos)
+		dir := w.GetDirection()
+		switch dir {
+		case entity.DirUp:
+			w.SetDirection(entity.DirDown)
+		case entity.DirDown:
+			w.SetDirection(entity.DirUp)
+		case entity.DirLeft:
+			w.SetDirection(entity.DirRight)
+		case entity.DirRight:
+			w.SetDirection(entity.DirLeft)
+		}
+	} else {
+		w.Move(dx, dy)
+	}
+}
+
+// resolve converts a position in a level to a map position.
+func (e *Engine) resolve(l *Level, x, y float64) (int, int) {
+	return l.Pos(x, y, e.gameMap.Width, e.gameMap.Height)
+}
+
+// Pos converts a position in the level to a position on a map of the given
+// size.
+func (l *Level) Pos(x, y float64, width, height int) (int, int) {
+	if ox, oy, ok := l.origin(width, height); ok {
+		return ox + int(x), oy + int(y)
+	}
+	return Resolve(x, width), Resolve(y, height)
+}
+
+// LevelPos converts a position on a map of the given size back to a
+// position in the level.
+func (l *Level) LevelPos(x, y, width, height int) (float64, float64) {
+	if ox, oy, ok := l.origin(width, height); ok {
+		return float64(x - ox), float64(y - oy)
+	}
+	return Relative(x, width), Relative(y, height)
+}
+
+// origin returns where the top left corner of the layout is placed on a map
+// of the given size, if positions in the level count from it.
+func (l *Level) origin(width, height int) (int, int, bool) {
+	if !l.FromLayout || len(l.Layout) == 0 {
+		return 0, 0, false
+	}
+	w := 0
+	for _, row := range l.Layout {
+		if n := len([]rune(row)); n > w {
+			w = n
+		}
+	}
+	return (width - w) / 2, (height - len(l.Layout)) / 2, true
+}
+
+// Resolve converts a level position on an axis of the given size to a map
+// position. The whole part counts cells and the fraction is that share of
+// the axis, so 1.25 is one cell past a quarter of the way across. Negative
+// values count back from the right or bottom edge.
+func Resolve(v float64, size int) int {
+	cells, part := math.Modf(v)
+	n := int(cells) + int(part*float64(size))
+	if v < 0 {
+		return size + n
+	}
+	return n
+}
+
+// Relative converts a map position back to a level position. Positions
+// past the middle of the axis count back from the far edge, so the level
+// keeps its shape on maps of other sizes.
+func Relative(v, size int) float64 {
+	if v > size/2 {
+		return float64(v - size)
+	}
+	return float64(v)
+}
+
+// ms converts a number of milliseconds to ticks.
+func ms(n int) int {
+	return ticks(time.Duration(n) * time.Millisecond)
+}
@@ -0,0 +1,85 @@
+package engine
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		v    float64
+		size int
+		want int
+	}{
+		{0, 100, 0},
+		{10, 100, 10},
+		{-1, 100, 99},
+		{-15, 100, 85},
+		{0.25, 100, 25},
+		{1.25, 100, 26},
+		{-0.25, 100, 75},
+		{-1.25, 100, 74},
+		{3.5, 35, 20},
+		{0.25, 35, 8},
+		{-0.25, 35, 27},
+	}
+	for _, tt := range tests {
+		if got := Resolve(tt.v, tt.size); got != tt.want {
+			t.Errorf("Resolve(%v, %v) = %v, want %v", tt.v, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestRelative(t *testing.T) {
+	tests := []struct {
+		v, size int
+		want    float64
+	}{
+		{1, 100, 1},
+		{50, 100, 50},
+		{51, 100, -49},
+		{98, 100, -2},
+		{17, 35, 17},
+		{18, 35, -17},
+	}
+	for _, tt := range tests {
+		if got := Relative(tt.v, tt.size); got != tt.want {
+			t.Errorf("Relative(%v, %v) = %v, want %v", tt.v, tt.size, got, tt.want)
+		}
+	}
+
+	// Every cell comes back to the same place
+	for _, size := range []int{24, 35, 100} {
+		for v := 0; v < size; v++ {
+			if got := Resolve(Relative(v, size), size); got != v {
+				t.Errorf("Resolve(Relative(%v, %v)) = %v", v, size, got)
+			}
+		}
+	}
+}
+
+func TestLevelPos(t *testing.T) {
+	l := &Level{Layout: []string{"#####", "#...#", "#####"}, FromLayout: true}
+	tests := []struct {
+		x, y          float64
+		width, height int
+		wantX, wantY  int
+	}{
+		{0, 0, 60, 24, 27, 10},
+		{2, 1, 60, 24, 29, 11},
+		{2, 1, 100, 35, 49, 17},
+		{-3, 5, 100, 35, 44, 21},
+	}
+	for _, tt := range tests {
+		x, y := l.Pos(tt.x, tt.y, tt.width, tt.height)
+		if x != tt.wantX || y != tt.wantY {
+			t.Errorf("Pos(%v, %v) on %vx%v = %v,%v, want %v,%v", tt.x, tt.y, tt.width, tt.height, x, y, tt.wantX, tt.wantY)
+		}
+		if lx, ly := l.LevelPos(x, y, tt.width, tt.height); lx != tt.x || ly != tt.y {
+			t.Errorf("LevelPos(%v, %v) on %vx%v = %v,%v, want %v,%v", x, y, tt.width, tt.height, lx, ly, tt.x, tt.y)
+		}
+	}
+
+	// Without FromLayout positions count from the map edges
+	l.FromLayout = false
+	if x, y := l.Pos(-1, 2, 60, 24); x != 59 || y != 2 {
+		t.Errorf("Pos(-1, 2) = %v,%v, want 59,2", x, y)
+	}
+}
@@ -0,0 +1,270 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// PuzzlePack is a named set of puzzles stored in one file.
+type PuzzlePack struct {
+	Name    string    `json:"name"`
+	Puzzles []*Puzzle `json:"puzzles"`
+}
+
+// Puzzle is a board where a snake of a fixed Length has to eat every bit
+// within Moves moves. Par is the number of moves a good solution takes.
+// The layout uses the level layout tiles and must have a spawn tile and at
+// least one bit tile.
+type Puzzle struct {
+	Name   string   `json:"name"`
+	Length int      `json:"length"`
+	Moves  int      `json:"moves"`
+	Par    int      `json:"par"`
+	Layout []string `json:"layout"`
+}
+
+// Level returns the level a puzzle is played on.
+func (p *Puzzle) Level() *Level {
+	return &Level{
+		Name:      p.Name,
+		Layout:    p.Layout,
+		FillBits:  true,
+		Objective: &Objective{AllBits: true},
+	}
+}
+
+// Validate checks that a puzzle can be played on a map of the given size
+// and that every bit can be reached from the spawn within the moves.
+func (p *Puzzle) Validate(width, height int) error {
+	if err := gamemap.CheckLayout(p.Layout, width, height); err != nil {
+		return err
+	}
+	if p.Length < 1 || p.Moves < 1 {
+		return fmt.Errorf("needs a length and moves")
+	}
+	if p.Par < 1 || p.Par > p.Moves {
+		return fmt.Errorf("par must be between 1 and the moves")
+	}
+	layout := strings.Join(p.Layout, "")
+	if !strings.ContainsRune(layout, gamemap.TileSpawn) || !strings.ContainsRune(layout, gamemap.TileBit) {
+		return fmt.Errorf("needs a spawn tile and a bit tile")
+	}
+	dist := p.distances()
+	for y, row := range p.Layout {
+		for x, c := range []rune(row) {
+			if c != gamemap.TileBit {
+				continue
+			}
+			d, ok := dist[gamemap.Point{X: x, Y: y}]
+			if !ok {
+				return fmt.Errorf("bit in row %v column %v can not be reached", y+1, x+1)
+			}
+			if d > p.Moves {
+				return fmt.Errorf("bit in row %v column %v is %v moves away but there are only %v", y+1, x+1, d, p.Moves)
+			}
+		}
+	}
+	return nil
+}
+
+// distances returns the fewest moves it takes to reach each tile of the
+// layout from its first spawn tile, going through portals and the floor
+// around the layout. The snake's own body is not in the way.
+func (p *Puzzle) distances() map[gamemap.Point]int {
+	tiles := make(map[gamemap.Point]rune)
+	ends := make(map[rune][]gamemap.Point)
+	var start []gamemap.Point
+	width := 0
+	for y, row := range p.Layout {
+		r := []rune(row)
+		if len(r) > width {
+			width = len(r)
+		}
+		for x, c := range r {
+			pt := gamemap.Point{X: x, Y: y}
+			tiles[pt] = c
+			if c == gamemap.TileSpawn {
+				start = append(start, pt)
+			} else if strings.ContainsRune(gamemap.TilePortals, c) {
+				ends[c] = append(ends[c], pt)
+			}
+		}
+	}
+	portals := make(map[gamemap.Point]gamemap.Point)
+	for _, e := range ends {
+		if len(e) == 2 {
+			portals[e[0]], portals[e[1]] = e[1], e[0]
+		}
+	}
+
+	dist := make(map[gamemap.Point]int)
+	if len(start) == 0 {
+		return dist
+	}
+	dist[start[0]] = 0
+	queue := start[:1]
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, d := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+			next := gamemap.Point{X: cur.X + d[0], Y: cur.Y + d[1]}
+			if to, ok := portals[next]; ok {
+				next = to
+			}
+			if next.X < -1 || next.Y < -1 || next.X > width || next.Y > len(p.Layout) {
+				continue
+			}
+			if _, ok := dist[next]; ok || tiles[next] == gamemap.TileWall {
+				continue
+			}
+			dist[next] = dist[cur] + 1
+			queue = append(queue, next)
+		}
+	}
+	return dist
+}
+
+// LoadPuzzles reads every puzzle pack file in dir ordered by file name and
+// checks that the puzzles fit on a map of the given size. If dir does not
+// exist it is created with the default puzzle pack.
+func LoadPuzzles(dir string, width, height int) ([]*PuzzlePack, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		packs := DefaultPuzzles()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		for i, pack := range packs {
+			file := filepath.Join(dir, fmt.Sprintf("pack%02d.json", i+1))
+			if err := WritePuzzlePack(pack, file); err != nil {
+				return nil, err
+			}
+		}
+		logger.Infof("Created default puzzles in: %v", dir)
+		return packs, ValidatePuzzles(packs, width, height)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var packs []*PuzzlePack
+	for _, file := range files {
+		byteValue, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pack, err := DecodePuzzlePack(byteValue)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+		packs = append(packs, pack)
+	}
+	logger.Infof("Loaded %v puzzle packs from: %v", len(packs), dir)
+
+	return packs, ValidatePuzzles(packs, width, height)
+}
+
+// ValidatePuzzles checks every puzzle in a list of packs. Puzzle names must
+// be unique within a pack.
+func ValidatePuzzles(packs []*PuzzlePack, width, height int) error {
+	if len(packs) == 0 {
+		return fmt.Errorf("no puzzle packs")
+	}
+	for _, pack := range packs {
+		if len(pack.Puzzles) == 0 {
+			return fmt.Errorf("pack %q: no puzzles", pack.Name)
+		}
+		names := make(map[string]bool)
+		for i, p := range pack.Puzzles {
+			if names[p.Name] {
+				return fmt.Errorf("pack %q puzzle %v: name %q is used more than once", pack.Name, i+1, p.Name)
+			}
+			names[p.Name] = true
+			if err := p.Validate(width, height); err != nil {
+				return fmt.Errorf("pack %q puzzle %v: %v", pack.Name, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DecodePuzzlePack converts JSON into a PuzzlePack. Unknown fields are an
+// error so that typos in puzzle files are caught.
+func DecodePuzzlePack(byteValue []byte) (*PuzzlePack, error) {
+	var pack PuzzlePack
+	dec := json.NewDecoder(bytes.NewReader(byteValue))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&pack); err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// WritePuzzlePack writes a puzzle pack to file.
+func WritePuzzlePack(pack *PuzzlePack, file string) error {
+	byteValue, _ := json.MarshalIndent(pack, "", " ")
+	return ioutil.WriteFile(file, byteValue, 0644)
+}
+
+// DefaultPuzzles returns the built in puzzle pack.
+func DefaultPuzzles() []*PuzzlePack {
+	return []*PuzzlePack{
+		{
+			Name: "Starter",
+			Puzzles: []*Puzzle{
+				{
+					Name:   "Hallway",
+					Length: 3,
+					Moves:  8,
+					Par:    6,
+					Layout: []string{
+						"#########",
+						"#S..B..B#",
+						"#########",
+					},
+				},
+				{
+					Name:   "Corners",
+					Length: 3,
+					Moves:  24,
+					Par:    16,
+					Layout: []string{
+						"#######",
+						"#B...B#",
+						"#.....#",
+						"#..S..#",
+						"#.....#",
+						"#B...B#",
+						"#######",
+					},
+				},
+				{
+					Name:   "Pillars",
+					Length: 5,
+					Moves:  30,
+					Par:    21,
+					Layout: []string{
+						"#########",
+						"#B.....B#",
+						"#.#.#.#.#",
+						"#...S...#",
+						"#.#.#.#.#",
+						"#B.....B#",
+						"#########",
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+// corners is the Corners puzzle with only the moves it takes to reach the
+// farthest bit.
+func corners() *Puzzle {
+	return &Puzzle{
+		Name:   "Corners",
+		Length: 1,
+		Moves:  4,
+		Par:    4,
+		Layout: []string{
+			"#######",
+			"#B...B#",
+			"#.....#",
+			"#..S..#",
+			"#.....#",
+			"#B...B#",
+			"#######",
+		},
+	}
+}
+
+// Size of the map puzzles are played on in the tests
+const puzzleWidth, puzzleHeight = 60, 24
+
+// puzzleEngine starts a single player match of a puzzle with the rules the
+// game sets up for it.
+func puzzleEngine(pz *Puzzle) *Engine {
+	return NewEngine(Config{
+		Width:     puzzleWidth,
+		Height:    puzzleHeight,
+		Players:   []PlayerConfig{{Name: "a", FGColor: "white", BGColor: "black", Char: 'a'}},
+		Levels:    []*Level{pz.Level()},
+		StepMoves: true,
+		MoveLimit: pz.Moves,
+		Length:    pz.Length,
+	})
+}
+
+// move steps a puzzle once with a direction for the first player and
+// returns the events of the tick.
+func move(e *Engine, action int) []Event {
+	return e.Step([]Input{{Player: 0, Action: action}})
+}
+
+func roundEnd(events []Event) (int, bool) {
+	for _, ev := range events {
+		if ev.Type == EventRound {
+			return ev.Player, true
+		}
+	}
+	return 0, false
+}
+
+func TestPuzzleFailsOutOfMoves(t *testing.T) {
+	e := puzzleEngine(corners())
+
+	// Walk a square around the spawn without eating a bit
+	actions := []int{ActionUp, ActionRight, ActionDown, ActionLeft}
+	for i, a := range actions {
+		events := move(e, a)
+		if e.GetMoves() != i+1 {
+			t.Fatalf("move %v counted %v moves", i+1, e.GetMoves())
+		}
+		winner, ended := roundEnd(events)
+		if i < len(actions)-1 && ended {
+			t.Fatalf("round ended after %v of %v moves", i+1, len(actions))
+		}
+		if i == len(actions)-1 && (!ended || winner != -1) {
+			t.Fatalf("last move gave round end %v with winner %v, want a loss", ended, winner)
+		}
+	}
+	s := e.Snapshot()
+	if s.Winner != -1 || s.Break == 0 {
+		t.Fatalf("got winner %v and break %v, want -1 and a break", s.Winner, s.Break)
+	}
+	if move(e, ActionUp); e.GetMoves() != len(actions) {
+		t.Fatalf("a move during the break was counted, got %v moves", e.GetMoves())
+	}
+}
+
+func TestPuzzleSolvedWithinMoves(t *testing.T) {
+	e := puzzleEngine(DefaultPuzzles()[0].Puzzles[0])
+	for i := 0; i < 6; i++ {
+		if winner, ended := roundEnd(move(e, ActionRight)); ended {
+			if i != 5 || winner != 0 {
+				t.Fatalf("round ended after %v moves with winner %v, want 6 moves and winner 0", i+1, winner)
+			}
+			return
+		}
+	}
+	t.Fatal("eating every bit did not end the round")
+}
+
+func TestStepMovesOnlyOnInput(t *testing.T) {
+	e := puzzleEngine(corners())
+	x, y := e.players[0].GetCurPos(0)
+
+	for i := 0; i < 500; i++ {
+		e.Step(nil)
+	}
+	if nx, ny := e.players[0].GetCurPos(0); nx != x || ny != y {
+		t.Fatalf("snake moved from %v,%v to %v,%v without input", x, y, nx, ny)
+	}
+
+	move(e, ActionUp)
+	if nx, ny := e.players[0].GetCurPos(0); nx != x || ny != y-1 {
+		t.Fatalf("got %v,%v after moving up from %v,%v, want one cell up", nx, ny, x, y)
+	}
+	if e.GetMoves() != 1 {
+		t.Fatalf("got %v moves, want 1", e.GetMoves())
+	}
+
+	// Turning back into the snake is no move at all
+	move(e, ActionDown)
+	if nx, ny := e.players[0].GetCurPos(0); nx != x || ny != y-1 || e.GetMoves() != 1 {
+		t.Fatalf("turning back moved to %v,%v with %v moves", nx, ny, e.GetMoves())
+	}
+}
+
+func TestPuzzleValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(p *Puzzle)
+		err  string // Part of the error, empty for none
+	}{
+		{"valid", func(p *Puzzle) {}, ""},
+		{"no moves", func(p *Puzzle) { p.Moves = 0 }, "moves"},
+		{"no length", func(p *Puzzle) { p.Length = 0 }, "length"},
+		{"par over moves", func(p *Puzzle) { p.Par = 5 }, "par"},
+		{"too few moves", func(p *Puzzle) { p.Moves, p.Par = 3, 3 }, "4 moves away"},
+		{"no spawn", func(p *Puzzle) { p.Layout[3] = "#.....#" }, "spawn"},
+		{"no bits", func(p *Puzzle) {
+			p.Layout[1], p.Layout[5] = "#.....#", "#.....#"
+		}, "bit"},
+		{"walled in bit", func(p *Puzzle) {
+			p.Layout[1], p.Layout[2] = "#B#..B#", "##....#"
+		}, "row 2 column 2 can not be reached"},
+		{"walled in spawn", func(p *Puzzle) {
+			p.Layout[2], p.Layout[3], p.Layout[4] = "#..#..#", "#.#S#.#", "#..#..#"
+		}, "can not be reached"},
+		{"through a portal", func(p *Puzzle) {
+			p.Layout[1], p.Layout[2] = "#B1#.B#", "####..#"
+			p.Layout[4] = "#1....#"
+		}, ""},
+		{"around the layout", func(p *Puzzle) {
+			p.Moves, p.Par = 24, 16
+			p.Layout[1], p.Layout[2] = ".B#..B#", "##....#"
+			p.Layout[3] = "...S..#"
+		}, ""},
+		{"wider than the map", func(p *Puzzle) {
+			p.Layout[0] = strings.Repeat("#", puzzleWidth+1)
+		}, "wide"},
+		{"higher than the map", func(p *Puzzle) {
+			for len(p.Layout) <= puzzleHeight {
+				p.Layout = append(p.Layout, "#######")
+			}
+		}, "high"},
+		{"unknown tile", func(p *Puzzle) { p.Layout[2] = "#..x..#" }, "unknown tile"},
+	}
+	for _, test := range tests {
+		p := corners()
+		test.edit(p)
+		err := p.Validate(puzzleWidth, puzzleHeight)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%v: got error %v", test.name, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%v: got no error, want one about %q", test.name, test.err)
+		case test.err != "" && !strings.Contains(err.Error(), test.err):
+			t.Errorf("%v: got error %v, want one about %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestDefaultPuzzlesValidate(t *testing.T) {
+	for _, pack := range DefaultPuzzles() {
+		for _, p := range pack.Puzzles {
+			if err := p.Validate(puzzleWidth, puzzleHeight); err != nil {
+				t.Errorf("%v: %v", p.Name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// checkRound ends the round when the level's objective is met, when the
+// moves or time run out or when at most one snake has lives left.
+func (e *Engine) checkRound() {
+	if e.over || e.breakTicks > 0 {
+		return
+	}
+	e.elapsed++
+	if o := e.currentLevel().Objective; o != nil && e.objectiveMet(o) {
+		e.endRound(e.roundWinner())
+		return
+	}
+	if e.config.MoveLimit > 0 && e.moves >= e.config.MoveLimit {
+		e.endRound(-1)
+		return
+	}
+	if e.config.RoundTime > 0 {
+		e.timeLeft--
+		if e.timeLeft <= 0 {
+			e.timeLeft = 0
+			e.endRound(e.roundWinner())
+			return
+		}
+	}
+	if e.config.Lives > 0 && len(e.players) > 1 {
+		alive := 0
+		for _, s := range e.stats {
+			if s.Lives > 0 {
+				alive++
+			}
+		}
+		if alive <= 1 {
+			e.endRound(e.roundWinner())
+		}
+	}
+}
+
+// currentLevel returns the definition of the level being played.
+func (e *Engine) currentLevel() *Level {
+	if e.config.Survival > 0 {
+		return e.stage
+	}
+	return e.levels[e.level-1]
+}
+
+// objectiveMet reports whether every part of an objective has been met.
+// The score is met as soon as any snake reaches it.
+func (e *Engine) objectiveMet(o *Objective) bool {
+	if o.Score > 0 {
+		top := 0
+		for _, p := range e.players {
+			if p.GetScore() > top {
+				top = p.GetScore()
+			}
+		}
+		if top < o.Score {
+			return false
+		}
+	}
+	if o.Survive > 0 && e.elapsed < ticks(time.Duration(o.Survive)*time.Second) {
+		return false
+	}
+	if o.AllBits && len(e.bits) > 0 {
+		return false
+	}
+	return true
+}
+
+// spawnTimeBit places a time bit every TimeBitEvery ticks if the round
+// has a time bonus and there is no time bit on the map.
+func (e *Engine) spawnTimeBit() {
+	if e.config.TimeBonus <= 0 || e.tick%TimeBitEvery != 0 {
+		return
+	}
+	for _, b := range e.bits {
+		if b.GetState() == entity.BitTime {
+			return
+		}
+	}
+	x, y := e.randomFreeCell()
+	e.bits = append(e.bits, entity.NewBit(x, y, 10, TimeBitRune, entity.BitTime, entity.DirNone, gamemap.SelStyle))
+}
+
+// endRound credits the winner of the round, -1 for a draw, and starts the
+// break before the next round.
+func (e *Engine) endRound(winner int) {
+	e.winner = winner
+	ev := Event{Type: EventRound, Player: winner}
+	if winner >= 0 {
+		e.stats[winner].Wins++
+		ev.Name = e.players[winner].GetName()
+		ev.Score = e.players[winner].GetScore()
+	}
+	e.events = append(e.events, ev)
+	e.breakTicks = RoundBreak
+}
+
+// endBreak starts the next round or ends the match after the last one, or
+// once the match is decided if it is played as a best of.
+func (e *Engine) endBreak() {
+	if e.round < e.config.Rounds && !e.decided() {
+		e.startRound()
+		return
+	}
+	e.over = true
+	ev := Event{Type: EventOver, Player: e.matchWinner()}
+	if ev.Player >= 0 {
+		ev.Name = e.players[ev.Player].GetName()
+		ev.Score = e.players[ev.Player].GetScore()
+	}
+	e.events = append(e.events, ev)
+}
+
+// decided reports whether a snake has won more than half the rounds of a
+// best of match.
+func (e *Engine) decided() bool {
+	if !e.config.BestOf {
+		return false
+	}
+	for _, s := range e.stats {
+		if s.Wins*2 > e.config.Rounds {
+			return true
+		}
+	}
+	return false
+}
+
+// roundWinner returns the player with the most lives left, then the most
+// kills this round and then the highest score, or -1 if there is a tie.
+func (e *Engine) roundWinner() int {
+	return best(len(e.players), func(i int) [3]int {
+		return [3]int{e.stats[i].Lives, e.stats[i].RoundKills, e.players[i].GetScore()}
+	})
+}
+
+// matchWinner returns the player who won the most rounds, then has the
+// most kills and then the fewest deaths, or -1 if there is a tie.
+func (e *Engine) matchWinner() int {
+	return best(len(e.players), func(i int) [3]int {
+		return [3]int{e.stats[i].Wins, e.stats[i].Kills, -e.stats[i].Deaths}
+	})
+}
+
+// best returns the index with the highest key, comparing the key values in
+// order, or -1 if the highest key is shared.
+func best(n int, key func(i int) [3]int) int {
+	winner, tie := -1, false
+	var top [3]int
+	for i := 0; i < n; i++ {
+		k := key(i)
+		switch {
+		case winner == -1 || greater(k, top):
+			winner, top, tie = i, k, false
+		case k == top:
+			tie = true
+		}
+	}
+	if tie {
+		return -1
+	}
+	return winner
+}
+
+// greater reports whether a is higher than b comparing values in order.
+func greater(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
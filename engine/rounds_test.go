@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// battle creates a three player match played in rounds with lives.
+func battle() *Engine {
+	return NewEngine(Config{
+		Width:  80,
+		Height: 30,
+		Rand:   rand.New(rand.NewSource(1)),
+		Lives:  3,
+		Rounds: 3,
+		Players: []PlayerConfig{
+			{Name: "a", FGColor: "white", BGColor: "black", Char: 'a'},
+			{Name: "b", FGColor: "red", BGColor: "black", Char: 'b'},
+			{Name: "c", FGColor: "blue", BGColor: "black", Char: 'c'},
+		},
+	})
+}
+
+func TestRoundWinner(t *testing.T) {
+	tests := []struct {
+		name   string
+		lives  [3]int
+		kills  [3]int
+		scores [3]int
+		want   int
+	}{
+		{"most lives", [3]int{1, 3, 2}, [3]int{2, 0, 1}, [3]int{90, 10, 50}, 1},
+		{"most kills", [3]int{2, 2, 1}, [3]int{1, 2, 3}, [3]int{90, 10, 50}, 1},
+		{"highest score", [3]int{2, 2, 1}, [3]int{1, 1, 3}, [3]int{90, 10, 50}, 0},
+		{"tie", [3]int{2, 2, 1}, [3]int{1, 1, 3}, [3]int{50, 50, 90}, -1},
+	}
+	for _, tt := range tests {
+		e := battle()
+		for i := range e.players {
+			e.stats[i].Lives = tt.lives[i]
+			e.stats[i].RoundKills = tt.kills[i]
+			e.players[i].SetScore(tt.scores[i])
+		}
+		if got := e.roundWinner(); got != tt.want {
+			t.Errorf("%v: got winner %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWinner(t *testing.T) {
+	tests := []struct {
+		name   string
+		wins   [3]int
+		kills  [3]int
+		deaths [3]int
+		want   int
+	}{
+		{"most wins", [3]int{1, 2, 0}, [3]int{5, 1, 0}, [3]int{0, 6, 0}, 1},
+		{"most kills", [3]int{1, 1, 1}, [3]int{2, 4, 3}, [3]int{0, 6, 0}, 1},
+		{"fewest deaths", [3]int{1, 1, 1}, [3]int{4, 4, 3}, [3]int{3, 2, 0}, 1},
+		{"tie", [3]int{1, 1, 0}, [3]int{4, 4, 3}, [3]int{2, 2, 0}, -1},
+	}
+	for _, tt := range tests {
+		e := battle()
+		for i := range e.players {
+			e.stats[i].Wins = tt.wins[i]
+			e.stats[i].Kills = tt.kills[i]
+			e.stats[i].Deaths = tt.deaths[i]
+
+			// The score of the last round does not count
+			e.players[i].SetScore(100 * i)
+		}
+		if got := e.matchWinner(); got != tt.want {
+			t.Errorf("%v: got winner %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRespawnKeepsRoundScore(t *testing.T) {
+	e := battle()
+	p := e.players[0]
+	p.SetScore(40)
+	e.killPlayer(0, p, 1)
+	for i := 0; i < 1000 && p.IsDead(); i++ {
+		e.Step(nil)
+	}
+	if p.IsDead() {
+		t.Fatal("the snake did not respawn")
+	}
+	if e.stats[0].Lives != 2 || p.GetScore() != 40 {
+		t.Fatalf("respawned with %v lives and %v points, want 2 and 40", e.stats[0].Lives, p.GetScore())
+	}
+}
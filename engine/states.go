@@ -0,0 +1,127 @@
+package engine
+
+import (
+	"github.com/stjiub/gosnake/entity"
+)
+
+// Player input actions
+const (
+	ActionUp = iota
+	ActionDown
+	ActionLeft
+	ActionRight
+	ActionItem
+)
+
+// Engine event types
+const (
+	EventDeath = iota
+	EventLevel
+	EventOver
+	EventKill  // Player killed another snake
+	EventRound // Round ended, Player is the winner or -1
+	EventGoal  // Co-op goal ended, Score is the bonus or 0 if it was missed
+)
+
+// Points a bit dropped by a dead snake is worth by default
+const DefaultDropPoints = 10
+
+// Ticks the scoreboard is shown for between rounds
+const RoundBreak = 500
+
+// Ticks between one co-op goal ending and the next one, and the points a
+// team gets for meeting a goal
+const (
+	GoalEvery = 1000
+	GoalBonus = 100
+)
+
+// Ticks between time bits appearing when there is none on the map
+const TimeBitEvery = 1000
+
+// How much less time a snake takes to move each time it speeds up and the
+// least time it can take, in percent of its normal move time
+const (
+	SpeedUpPercent  = 5
+	MinSpeedPercent = 40
+)
+
+// Survival hazards. Each stage comes SurvivalSpeedUp percent sooner down to
+// SurvivalMinPercent of the starting time. Bite spawners start adding bites
+// every SurvivalBiteEvery ms and every stage SurvivalBiteFaster ms sooner.
+// Moving walls are placed at least SurvivalWallDistance cells from a snake.
+const (
+	SurvivalSpeedUp      = 5
+	SurvivalMinPercent   = 25
+	SurvivalBiteEvery    = 15000
+	SurvivalBiteFaster   = 500
+	SurvivalMinBiteEvery = 2000
+	SurvivalWallSegments = 6
+	SurvivalWallDistance = 8
+)
+
+// Portals placed at random are kept at least PortalDistance cells from the
+// snakes
+const PortalDistance = 3
+
+// Item effects. Slow motion and speed boosts change how long a snake takes
+// to move, in percent. Magnets pull bits within MagnetRange cells one cell
+// closer every MagnetTicks ticks.
+const (
+	SlowMoPercent = 150
+	BoostPercent  = 60
+	MagnetRange   = 6
+	MagnetTicks   = 10
+)
+
+// Milliseconds that items placed by a layout last
+const DefaultItemDuration = 3000
+
+// Game runes
+const (
+	BitRune         rune = '■'
+	WallRune        rune = '▒'
+	FloorRune       rune = ' '
+	ItemRune        rune = '*'
+	TimeBitRune     rune = '+'
+	GoalRune        rune = '●'
+	PortalRune      rune = '◎'
+	BiteUpRune      rune = '▲'
+	BiteDownRune    rune = '▼'
+	BiteLeftRune    rune = '◄'
+	BiteRightRune   rune = '►'
+	BiteAllRune     rune = '◆'
+	BiteExplodeRune rune = '░'
+)
+
+var (
+	BiteRunes = []rune{BiteUpRune, BiteDownRune, BiteLeftRune, BiteRightRune, BiteAllRune, BiteExplodeRune}
+
+	// Names used for directions in level files
+	directions = map[string]int{
+		"up":    entity.DirUp,
+		"down":  entity.DirDown,
+		"left":  entity.DirLeft,
+		"right": entity.DirRight,
+	}
+	biteDirections = map[string]int{
+		"up":    entity.DirUp,
+		"down":  entity.DirDown,
+		"left":  entity.DirLeft,
+		"right": entity.DirRight,
+		"all":   entity.DirAll,
+	}
+
+	// Starting positions in quarters of the map and starting directions
+	// for each player when there is more than one
+	spawnSpots = [][2]int{{1, 1}, {3, 3}, {3, 1}, {1, 3}}
+	spawnDirs  = []int{entity.DirRight, entity.DirLeft, entity.DirDown, entity.DirUp}
+
+	// Direction a snake takes for each direction action
+	actionDirs = map[int]int{
+		ActionUp:    entity.DirUp,
+		ActionDown:  entity.DirDown,
+		ActionLeft:  entity.DirLeft,
+		ActionRight: entity.DirRight,
+	}
+)
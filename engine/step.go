@@ -0,0 +1,531 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// Simulation timing values. Every interval in the game is a whole
+// number of ticks so that a run only depends on the inputs it receives.
+const (
+	TickDuration = 10 * time.Millisecond
+
+	// Time to move one cell for snakes and moving walls. The up and down
+	// directions are slower in an attempt to even out the direction movement
+	// speeds, because of the way the terminal is designed vertical movement
+	// is normally much faster than horizontal.
+	DefaultHorizontalMove = 80 * time.Millisecond
+	DefaultVerticalMove   = 140 * time.Millisecond
+
+	// Bite explosion timings in ticks
+	BiteFuse   = 50
+	BiteSpread = 3
+	BiteLinger = 1000
+
+	// Ticks between random bit movements
+	BitMoveTicks = 50
+
+	// Ticks between death animation frames
+	DecayTicks = 2
+)
+
+// explosion tracks a bite that has been triggered by a player.
+type explosion struct {
+	bite *entity.Bit
+	tick int
+}
+
+// Step advances the game by exactly one tick and returns the events that
+// happened during it. Inputs are applied first, then players, moving walls,
+// bits, spawners, explosions, items and finally the level are updated,
+// always in that order.
+func (e *Engine) Step(inputs []Input) []Event {
+	e.tick++
+	e.events = nil
+	if e.over {
+		return nil
+	}
+
+	// Nothing moves during the break between rounds
+	if e.breakTicks > 0 {
+		e.breakTicks--
+		if e.breakTicks == 0 {
+			e.endBreak()
+		}
+		return e.events
+	}
+
+	for _, in := range inputs {
+		e.applyInput(in)
+	}
+	for i, p := range e.players {
+		e.stepPlayer(i, p)
+	}
+	for _, w := range e.entities {
+		if w.Ready(moveInterval(DefaultHorizontalMove, DefaultVerticalMove, w.GetSpeed(), w.GetDirection())) {
+			stepWall(w, e.gameMap)
+		}
+	}
+	if e.moveBits && e.tick%BitMoveTicks == 0 {
+		for i := range e.bits {
+			if e.bits[i].GetState() == entity.BitRandom {
+				e.bits[i].MoveRandom(e.rng, e.gameMap)
+			}
+		}
+	}
+	for _, s := range e.spawners {
+		if s.wait > 0 {
+			s.wait--
+			continue
+		}
+		s.spawn(e)
+		s.wait = s.every - 1
+	}
+	e.spawnTimeBit()
+	e.stepGoal()
+	e.stepExplosions()
+	for _, p := range e.players {
+		p.StepItems()
+	}
+	if e.tick%MagnetTicks == 0 {
+		e.stepMagnets()
+	}
+	if e.config.Survival > 0 {
+		e.stepSurvival()
+	} else {
+		e.handleLevel()
+	}
+	e.checkRound()
+
+	return e.events
+}
+
+// applyInput changes a player's direction or activates their item.
+func (e *Engine) applyInput(in Input) {
+	if in.Player < 0 || in.Player >= len(e.players) {
+		return
+	}
+	p := e.players[in.Player]
+	if p.IsDead() {
+		return
+	}
+	dir := p.GetDirection()
+
+	// Prevent player from turning into themselves
+	switch in.Action {
+	case ActionUp:
+		if !(dir == entity.DirDown) {
+			p.SetDirection(entity.DirUp)
+		}
+	case ActionDown:
+		if !(dir == entity.DirUp) {
+			p.SetDirection(entity.DirDown)
+		}
+	case ActionLeft:
+		if !(dir == entity.DirRight) {
+			p.SetDirection(entity.DirLeft)
+		}
+	case ActionRight:
+		if !(dir == entity.DirLeft) {
+			p.SetDirection(entity.DirRight)
+		}
+	case ActionItem:
+		p.ActivateItem()
+		return
+	}
+
+	// Every direction the snake can take is one move
+	if e.config.StepMoves && p.GetDirection() == actionDirs[in.Action] {
+		e.moves++
+		e.movePlayer(in.Player, p)
+	}
+}
+
+// setLength gives a new snake the configured starting length.
+func (e *Engine) setLength(p *entity.Player) {
+	if e.config.Length > 1 {
+		p.AddSegment(e.config.Length-1, p.GetChar(0), p.GetStyle(0))
+	}
+}
+
+// stepPlayer moves a player if their movement timer is up. Snakes that
+// only move when given a direction are moved by applyInput instead.
+func (e *Engine) stepPlayer(i int, p *entity.Player) {
+	if p.IsDead() {
+		if p.Ready(DecayTicks) && p.Decay(gamemap.BiteExplodedStyle) {
+			if e.config.TeamLives > 0 && e.teamLives == 0 {
+				// The team is out of lives
+				if !e.over {
+					e.over = true
+					e.events = append(e.events, Event{Type: EventOver, Player: -1, Score: e.teamScore()})
+				}
+			} else if e.config.Lives > 0 && e.stats[i].Lives == 0 {
+				// Out of lives, a single player's round is over
+				if len(e.players) == 1 {
+					e.endRound(-1)
+				}
+			} else if len(e.players) == 1 && e.config.Lives == 0 && e.config.CrashPenalty == 0 {
+				e.over = true
+				e.events = append(e.events, Event{Type: EventOver, Player: i, Name: p.GetName()})
+			} else {
+				pc := e.config.Players[i]
+				x, y, dir := e.spawnPoint(i)
+				score := p.GetScore()
+				p.Reset(x, y, dir, gamemap.ColorStyle(pc.FGColor, pc.BGColor))
+				e.setLength(p)
+
+				// The score is kept when crashing only costs time or a
+				// life, or when there are no other snakes to beat
+				if e.config.CrashPenalty > 0 || e.config.TeamLives > 0 || e.config.Lives > 0 || len(e.players) == 1 {
+					p.SetScore(score)
+				}
+			}
+		}
+		return
+	}
+	if e.config.StepMoves {
+		return
+	}
+	h, v := e.moveTimes(p)
+	if !p.Ready(moveInterval(h, v, p.GetSpeed(), p.GetDirection())) {
+		return
+	}
+	e.movePlayer(i, p)
+}
+
+// movePlayer moves a player one cell in their direction and handles their
+// interaction with objects on the map.
+func (e *Engine) movePlayer(i int, p *entity.Player) {
+	// Check which direction player should be moving
+	dx, dy := e.nextMove(p)
+
+	// Check if player is blocked at all. Running into another snake
+	// counts as a kill for that snake. A trail is under its snake's body
+	// so it counts too while the snake is there. A shield is used up
+	// instead and turns the snake aside if it can.
+	others := e.others()
+	if p.IsBlocked(e.gameMap, e.biteMap, e.entities, others, dx, dy) {
+		if p.HasEffect(entity.Shield) {
+			p.UseEffect(entity.Shield)
+			e.dodge(p)
+			return
+		}
+		killer := -1
+		if e.config.Trails || !p.IsBlockedByMap(e.gameMap, dx, dy) {
+			killer = p.BlockingPlayer(others, dx, dy)
+		}
+		e.killPlayer(i, p, killer)
+		return
+	}
+
+	// Move player if not blocked
+	x, y := p.GetCurPos(0)
+	p.Move(dx, dy)
+	if e.config.Trails {
+		e.leaveTrail(p, x, y)
+	}
+
+	// Check if player is on a bit or bite
+	if b := e.IsOnBit(p); b != -1 {
+		if e.bits[b].GetState() == entity.BitGoal {
+			e.goalEaten[i] = true
+		}
+		e.bits = removeBit(e.bits, b)
+	}
+	if b := e.IsOnBite(p); b != -1 {
+		e.bites = removeBit(e.bites, b)
+	}
+	e.IsOnItem(p)
+}
+
+// leaveTrail grows a snake back over the cell it just left and turns that
+// cell into a wall. The wall looks like the snake's body once it has
+// exploded so it stays the same after the snake is gone.
+func (e *Engine) leaveTrail(p *entity.Player, x, y int) {
+	p.AddSegment(1, p.GetChar(0), p.GetStyle(0))
+	e.gameMap.Objects[x][y] = gamemap.NewObject(x, y, p.GetChar(0), gamemap.BiteExplodedStyle, true)
+}
+
+// nextMove returns the move a player makes next in their direction, going
+// round the map and through portals.
+func (e *Engine) nextMove(p *entity.Player) (int, int) {
+	dx, dy := p.CheckDirection()
+	if e.config.Wrap || e.config.OpenBorder {
+		dx, dy = e.wrap(p, dx, dy)
+	}
+	return e.portal(p, dx, dy)
+}
+
+// others returns the snakes that block a player.
+func (e *Engine) others() []*entity.Player {
+	if e.config.PassThrough {
+		return nil
+	}
+	return e.players
+}
+
+// dodge turns a snake to the first side it can move to without crashing.
+// The snake keeps its direction if both sides are blocked.
+func (e *Engine) dodge(p *entity.Player) {
+	dir := p.GetDirection()
+	sides := []int{entity.DirUp, entity.DirDown}
+	if dir == entity.DirUp || dir == entity.DirDown {
+		sides = []int{entity.DirLeft, entity.DirRight}
+	}
+	for _, side := range sides {
+		p.SetDirection(side)
+		dx, dy := e.nextMove(p)
+		if !p.IsBlocked(e.gameMap, e.biteMap, e.entities, e.others(), dx, dy) {
+			return
+		}
+	}
+	p.SetDirection(dir)
+}
+
+// stepMagnets pulls the bits near each snake with a magnet one cell closer
+// to the cell in front of its head, so they line up to be eaten.
+func (e *Engine) stepMagnets() {
+	m := e.gameMap
+	for _, p := range e.players {
+		if p.IsDead() || !p.HasEffect(entity.Magnet) {
+			continue
+		}
+		hx, hy := p.GetCurPos(0)
+		dx, dy := p.CheckDirection()
+		px, py := m.WrapPos(hx+dx, hy+dy)
+		for _, b := range e.bits {
+			bx, by := b.GetCurPos()
+			if dx, dy := m.Dist(px, py, bx, by); dx > MagnetRange || dy > MagnetRange {
+				continue
+			}
+			x, y := m.WrapPos(bx+toward(bx, px, m.Width, m.Wrap), by+toward(by, py, m.Height, m.Wrap))
+			if _, portal := m.Portal(x, y); !portal && !m.Objects[x][y].IsBlocked() {
+				b.SetPos(x, y)
+			}
+		}
+	}
+}
+
+// toward returns the step from a to b along a line of the given size,
+// going the shorter way round if it wraps.
+func toward(a, b, size int, wrap bool) int {
+	d := b - a
+	if wrap && (d > size/2 || d < -size/2) {
+		d = -d
+	}
+	switch {
+	case d > 0:
+		return 1
+	case d < 0:
+		return -1
+	}
+	return 0
+}
+
+// portal changes a move onto a portal into a move onto its partner, so the
+// snake comes out of the partner going the same way. The body follows the
+// head through cell by cell.
+func (e *Engine) portal(p *entity.Player, dx, dy int) (int, int) {
+	x, y := p.GetCurPos(0)
+	if to, ok := e.gameMap.Portal(x+dx, y+dy); ok {
+		return to.X - x, to.Y - y
+	}
+	return dx, dy
+}
+
+// moveTimes returns how long a player takes to move sideways and up or
+// down. Players speed up as their score goes up if SpeedUp is set, and
+// slow motion and speed boost items change their speed on top of that.
+func (e *Engine) moveTimes(p *entity.Player) (time.Duration, time.Duration) {
+	h, v := e.config.HorizontalMove, e.config.VerticalMove
+	pct := 100
+	if e.config.SpeedUp > 0 {
+		pct -= SpeedUpPercent * (p.GetScore() / e.config.SpeedUp)
+		if pct < MinSpeedPercent {
+			pct = MinSpeedPercent
+		}
+	}
+	if p.HasEffect(entity.SlowMo) {
+		pct = pct * SlowMoPercent / 100
+	}
+	if p.HasEffect(entity.SpeedBoost) {
+		pct = pct * BoostPercent / 100
+	}
+	return h * time.Duration(pct) / 100, v * time.Duration(pct) / 100
+}
+
+// wrap changes a move onto the edge of the map into a move to the cell
+// just inside the opposite edge, or a move off an open map into a move onto
+// the opposite edge.
+func (e *Engine) wrap(p *entity.Player, dx, dy int) (int, int) {
+	x, y := p.GetCurPos(0)
+	if e.gameMap.Wrap {
+		return e.gameMap.WrapMove(x, y, dx, dy)
+	}
+	w, h := e.gameMap.Width, e.gameMap.Height
+	switch {
+	case x+dx <= 0:
+		dx = w - 2 - x
+	case x+dx >= w-1:
+		dx = 1 - x
+	}
+	switch {
+	case y+dy <= 0:
+		dy = h - 2 - y
+	case y+dy >= h-1:
+		dy = 1 - y
+	}
+	return dx, dy
+}
+
+// killPlayer reports a player's death and starts their death animation.
+// The killer is the player they ran into or -1.
+func (e *Engine) killPlayer(i int, p *entity.Player, killer int) {
+	name := p.GetName()
+	e.events = append(e.events, Event{Type: EventDeath, Player: i, Name: name, Score: p.GetScore()})
+
+	e.stats[i].Deaths++
+	if e.stats[i].Lives > 0 {
+		e.stats[i].Lives--
+	}
+	if e.teamLives > 0 {
+		e.teamLives--
+	}
+	if e.config.CrashPenalty > 0 {
+		e.timeLeft -= ticks(e.config.CrashPenalty)
+	}
+	if killer >= 0 && killer != i {
+		e.stats[killer].Kills++
+		e.stats[killer].RoundKills++
+		k := e.players[killer]
+		e.events = append(e.events, Event{Type: EventKill, Player: killer, Name: k.GetName(), Score: k.GetScore()})
+		logger.Infof("%v killed %v", k.GetName(), name)
+	}
+
+	// Other players can collect the dead player's body, unless they are on
+	// the same team or it is a trail
+	if len(e.players) > 1 && e.config.TeamLives == 0 && !e.config.Trails {
+		e.bits = p.DropBits(e.bits, e.config.DropPoints, BitRune, entity.BitRandom, gamemap.DefStyle)
+	}
+	p.Kill()
+	logger.Infof("Player died: %v", name)
+}
+
+// stepExplosions advances every triggered bite explosion and forgets the
+// ones that have finished.
+func (e *Engine) stepExplosions() {
+	var active []*explosion
+	for _, ex := range e.explosions {
+		ex.tick++
+		if !ex.bite.ExplodeBite(ex.tick, e.gameMap, e.biteMap, BiteExplodeRune, gamemap.BiteExplodedStyle, gamemap.DefStyle, BiteFuse, BiteSpread, BiteLinger) {
+			active = append(active, ex)
+		}
+	}
+	e.explosions = active
+}
+
+// handleLevel checks the current score against the level scores and
+// changes to the highest level any player has reached.
+func (e *Engine) handleLevel() {
+	for _, p := range e.players {
+		score := p.GetScore()
+		if e.config.TeamLives > 0 {
+			score = e.teamScore()
+		}
+		for l := len(e.levels); l > e.level; l-- {
+			if score >= e.levels[l-1].Score {
+				e.applyLevel(e.levels[l-1])
+				e.setLevel(l, p.GetName())
+				break
+			}
+		}
+	}
+}
+
+// setLevel records a level change and reports it.
+func (e *Engine) setLevel(level int, name string) {
+	e.level = level
+	e.events = append(e.events, Event{Type: EventLevel, Name: name, Level: level})
+	logger.Infof("%v reached level %v!", name, level)
+}
+
+// IsOnBit checks if player is on top of a bit
+func (e *Engine) IsOnBit(p *entity.Player) int {
+	i := p.CheckBitPos(e.bits)
+	if i != -1 {
+		b := e.bits[i]
+		points := b.GetPoints() * (1 + p.EffectCount(entity.Multiplier))
+		char := p.GetChar(0)
+		style := p.GetStyle(0)
+		p.AddScore(points)
+		if e.config.Length == 0 {
+			p.AddSegment(1, char, style)
+		}
+		if b.GetState() == entity.BitTime {
+			e.timeLeft += ticks(e.config.TimeBonus)
+		}
+	}
+	return i
+}
+
+// Determine if player is on a bite and if so trigger explosion
+func (e *Engine) IsOnBite(p *entity.Player) int {
+	i := p.CheckBitePos(e.bites)
+	if i != -1 {
+		b := e.bites[i]
+		char := p.GetChar(0)
+		style := p.GetStyle(0)
+		p.AddScore(50)
+		p.AddSegment(4, char, style)
+		e.explosions = append(e.explosions, &explosion{bite: b})
+		return i
+	}
+	return -1
+}
+
+func (e *Engine) IsOnItem(p *entity.Player) {
+	i := p.CheckItemPos(e.items)
+	if i != -1 {
+		p.AddItem(e.items[i])
+		e.removeItem(i)
+	}
+}
+
+func (e *Engine) removeItem(i int) {
+	e.items[i] = e.items[len(e.items)-1]
+	e.items[len(e.items)-1] = nil
+	e.items = e.items[:len(e.items)-1]
+}
+
+func removeBit(bits []*entity.Bit, i int) []*entity.Bit {
+	bits[i] = bits[len(bits)-1]
+	bits[len(bits)-1] = nil
+	bits = bits[:len(bits)-1]
+	return bits
+}
+
+// moveInterval calculates how many ticks an entity waits between moves
+// given the time it takes to move sideways and up or down. An entity's speed
+// divides the interval, so a speed of 2 moves twice as often as a speed of 1.
+func moveInterval(horizontal, vertical time.Duration, speed, direction int) int {
+	t := ticks(horizontal)
+	switch direction {
+	case entity.DirUp, entity.DirDown:
+		t = ticks(vertical)
+	}
+	if speed > 1 {
+		t /= speed
+	}
+	if t < 1 {
+		t = 1
+	}
+	return t
+}
+
+// ticks converts a duration to a whole number of simulation ticks.
+func ticks(d time.Duration) int {
+	return int(d / TickDuration)
+}
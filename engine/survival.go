@@ -0,0 +1,96 @@
+package engine
+
+import "time"
+
+// stepSurvival counts down to the next hazard and adds it to the current
+// level. Each hazard comes sooner than the one before it. Every snake that
+// is alive also scores a point per second.
+func (e *Engine) stepSurvival() {
+	if e.tick%ticks(time.Second) == 0 {
+		for _, p := range e.players {
+			if !p.IsDead() {
+				p.AddScore(1)
+			}
+		}
+	}
+	e.hazardWait--
+	if e.hazardWait > 0 {
+		return
+	}
+	e.applyLevel(e.nextStage())
+	name := ""
+	if len(e.players) > 0 {
+		name = e.players[0].GetName()
+	}
+	e.setLevel(e.level+1, name)
+	e.hazardWait = hazardTicks(e.config.Survival, e.level)
+}
+
+// nextStage returns a copy of the current survival level with one more
+// hazard. Stages take turns adding a bite spawner, a moving wall and a
+// spawner of bites that explode in a random direction.
+func (e *Engine) nextStage() *Level {
+	prev := e.stage
+	l := *prev
+	l.Name = "Survival"
+	l.Bites = append([]BiteSpawn(nil), prev.Bites...)
+	l.MovingWalls = append([]MovingWall(nil), prev.MovingWalls...)
+
+	n := e.level
+	every := SurvivalBiteEvery - n*SurvivalBiteFaster
+	if every < SurvivalMinBiteEvery {
+		every = SurvivalMinBiteEvery
+	}
+	switch n % 3 {
+	case 1:
+		l.Bites = append(l.Bites, BiteSpawn{Gen: 1, Max: 1 + n/3, Every: every})
+	case 2:
+		l.MovingWalls = append(l.MovingWalls, e.randomWall(&l, SurvivalWallSegments+n/2))
+	default:
+		l.Bites = append(l.Bites, BiteSpawn{Gen: 1, Max: 1 + n/3, Every: every, Random: true})
+		l.MoveBits = true
+	}
+	e.stage = &l
+	return &l
+}
+
+// randomWall places a moving wall of level l on a free cell in a random
+// direction, trying to keep it away from the heads of the snakes.
+func (e *Engine) randomWall(l *Level, segments int) MovingWall {
+	names := []string{"up", "down", "left", "right"}
+	var x, y int
+	for try := 0; try < 10; try++ {
+		x, y = e.randomFreeCell()
+		if !e.nearPlayer(x, y, SurvivalWallDistance) {
+			break
+		}
+	}
+	lx, ly := l.LevelPos(x, y, e.gameMap.Width, e.gameMap.Height)
+	return MovingWall{X: lx, Y: ly, Direction: names[e.rng.Intn(len(names))], Speed: 1, Segments: segments}
+}
+
+// nearPlayer reports whether a position is within d cells of the head of
+// a living snake.
+func (e *Engine) nearPlayer(x, y, d int) bool {
+	for _, p := range e.players {
+		if p.IsDead() {
+			continue
+		}
+		px, py := p.GetCurPos(0)
+		if dx, dy := e.gameMap.Dist(px, py, x, y); dx <= d && dy <= d {
+			return true
+		}
+	}
+	return false
+}
+
+// hazardTicks returns how long to wait before the hazard after the given
+// stage, SurvivalSpeedUp percent shorter for every stage down to
+// SurvivalMinPercent of the starting time.
+func hazardTicks(d time.Duration, stage int) int {
+	pct := 100 - SurvivalSpeedUp*(stage-1)
+	if pct < SurvivalMinPercent {
+		pct = SurvivalMinPercent
+	}
+	return ticks(d) * pct / 100
+}
@@ -2,9 +2,7 @@ package entity
 
 import (
 	"math/rand"
-	"time"
 
-	"github.com/gdamore/tcell"
 	"github.com/stjiub/gosnake/gamemap"
 )
 
@@ -15,6 +13,8 @@ const (
 	BitMoving
 	BitRandom
 	Bite
+	BitTime // Adds time to the clock when eaten
+	BitGoal // Part of a co-op goal
 )
 
 const (
@@ -39,7 +39,7 @@ type Bits interface {
 }
 
 // Create new Bit
-func NewBit(x, y, points int, char rune, state, dir int, style tcell.Style) *Bit {
+func NewBit(x, y, points int, char rune, state, dir int, style gamemap.Style) *Bit {
 	o := gamemap.NewObject(x, y, char, style, false)
 	b := Bit{
 		o,
@@ -51,7 +51,7 @@ func NewBit(x, y, points int, char rune, state, dir int, style tcell.Style) *Bit
 }
 
 // Create a horizontal line of bits of a given length
-func NewBitLineH(bits []*Bit, x, y, points, numBits int, char rune, style tcell.Style) []*Bit {
+func NewBitLineH(bits []*Bit, x, y, points, numBits int, char rune, style gamemap.Style) []*Bit {
 	for i := 0; i < numBits; i++ {
 		x += 2
 		b := NewBit(x, y, points, char, 0, DirNone, style)
@@ -61,7 +61,7 @@ func NewBitLineH(bits []*Bit, x, y, points, numBits int, char rune, style tcell.
 }
 
 // Create a vertical line of bits of a given length
-func NewBitLineV(bits []*Bit, x, y, points, numBits int, char rune, style tcell.Style) []*Bit {
+func NewBitLineV(bits []*Bit, x, y, points, numBits int, char rune, style gamemap.Style) []*Bit {
 	for i := 0; i < numBits; i++ {
 		y++
 		b := NewBit(x, y, points, char, 0, DirNone, style)
@@ -71,33 +71,35 @@ func NewBitLineV(bits []*Bit, x, y, points, numBits int, char rune, style tcell.
 }
 
 // Generate random coordinates for a Bit
-func NewRandomBit(m *gamemap.GameMap, points int, char rune, style tcell.Style) *Bit {
-	var b *Bit
+func NewRandomBit(r *rand.Rand, m *gamemap.GameMap, points int, char rune, style gamemap.Style) *Bit {
+	x, y := RandomCell(r, m)
+	return NewBit(x, y, points, char, 2, DirNone, style)
+}
+
+// RandomCell returns a random cell of the map that a bit can go on.
+func RandomCell(r *rand.Rand, m *gamemap.GameMap) (int, int) {
 	for {
-		randX := rand.Intn(m.Width)
-		randY := rand.Intn(m.Height)
-		if randX < m.Width-1 && randX > 1 && randY < m.Height-1 && randY > 1 {
-			b = NewBit(randX, randY, points, char, 2, DirNone, style)
-			break
+		x, y := r.Intn(m.Width), r.Intn(m.Height)
+		if inBounds(m, x, y) && free(m, x, y) {
+			return x, y
 		}
 	}
-	return b
 }
 
 // Generate random coordinates for a Bit line
-func NewRandomBitLine(bits []*Bit, m *gamemap.GameMap, points int, char rune, style tcell.Style) []*Bit {
+func NewRandomBitLine(r *rand.Rand, bits []*Bit, m *gamemap.GameMap, points int, char rune, style gamemap.Style) []*Bit {
 	for {
-		randNum := rand.Intn(6) + 2
-		randDir := randBool()
-		randX := rand.Intn(m.Width)
-		randY := rand.Intn(m.Height)
+		randNum := r.Intn(6) + 2
+		randDir := randBool(r)
+		randX := r.Intn(m.Width)
+		randY := r.Intn(m.Height)
 		if randDir {
-			if randX < ((m.Width-1)-(randNum*2)) && randX > 1 && randY < m.Height-1 && randY > 1 {
+			if inBounds(m, randX, randY) && inBounds(m, randX+randNum*2, randY) && lineClear(m, randX, randY, 2, 0, randNum) {
 				bits = NewBitLineH(bits, randX, randY, points, randNum, char, style)
 				return bits
 			}
 		} else {
-			if randX < m.Width-1 && randX > 1 && randY < ((m.Height-1)-randNum) && randY > 1 {
+			if inBounds(m, randX, randY) && inBounds(m, randX, randY+randNum) && lineClear(m, randX, randY, 0, 1, randNum) {
 				bits = NewBitLineV(bits, randX, randY, points, randNum, char, style)
 				return bits
 			}
@@ -106,35 +108,66 @@ func NewRandomBitLine(bits []*Bit, m *gamemap.GameMap, points int, char rune, st
 	}
 }
 
+// Check that a bit can be placed at a position. Bits are kept off the
+// border unless the map wraps.
+func inBounds(m *gamemap.GameMap, x, y int) bool {
+	if m.Wrap {
+		return x >= 0 && x < m.Width && y >= 0 && y < m.Height
+	}
+	return x < m.Width-1 && x > 1 && y < m.Height-1 && y > 1
+}
+
+// Check that a bit can go on a position. Bits stay off walls and portals.
+func free(m *gamemap.GameMap, x, y int) bool {
+	_, portal := m.Portal(x, y)
+	return !portal && !m.Objects[x][y].IsBlocked()
+}
+
+// Check that none of the cells a line of bits would cover are blocked
+func lineClear(m *gamemap.GameMap, x, y, dx, dy, n int) bool {
+	for i := 0; i < n; i++ {
+		x, y = x+dx, y+dy
+		if !free(m, x, y) {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *Bit) GetState() int {
 	return b.state
 }
 
+func (b *Bit) SetState(state int) {
+	b.state = state
+}
+
 func (b *Bit) GetPoints() int {
 	return b.points
 }
 
 // Move a Bit in random direction
-func (b *Bit) MoveRandom(m *gamemap.GameMap) {
-	r := [2]int{0, 0}
-	for i := range r {
-		random := randBool()
+func (b *Bit) MoveRandom(r *rand.Rand, m *gamemap.GameMap) {
+	d := [2]int{0, 0}
+	for i := range d {
+		random := randBool(r)
 		if random {
-			r[i] = 1
+			d[i] = 1
 		}
-		random = randBool()
+		random = randBool(r)
 		if random {
-			r[i] -= (r[i] * 2)
+			d[i] -= (d[i] * 2)
 		}
 	}
 	bx, by := b.GetCurPos()
-	if !m.Objects[r[0]+bx][r[1]+by].IsBlocked() {
-		b.Move(r[0], r[1])
+	x, y := m.WrapPos(bx+d[0], by+d[1])
+	if free(m, x, y) {
+		b.SetPos(x, y)
 	}
 }
 
 // NewRandomBite generates random coordinates and random explosion directions for a new Bite.
-func NewRandomBite(m *gamemap.GameMap, runes []rune, style tcell.Style, random bool) *Bit {
+func NewRandomBite(r *rand.Rand, m *gamemap.GameMap, runes []rune, style gamemap.Style, random bool) *Bit {
 	var (
 		bite *Bit
 		dir  int
@@ -143,7 +176,7 @@ func NewRandomBite(m *gamemap.GameMap, runes []rune, style tcell.Style, random b
 
 	for {
 		if random {
-			randDir := rand.Intn(4)
+			randDir := r.Intn(4)
 			switch randDir {
 			case DirUp:
 				dir = DirUp
@@ -165,9 +198,9 @@ func NewRandomBite(m *gamemap.GameMap, runes []rune, style tcell.Style, random b
 			dir = DirAll
 			char = runes[DirAll]
 		}
-		randX := rand.Intn(m.Width)
-		randY := rand.Intn(m.Height)
-		if randX < m.Width-1 && randX > 1 && randY < m.Height-1 && randY > 1 {
+		randX := r.Intn(m.Width)
+		randY := r.Intn(m.Height)
+		if inBounds(m, randX, randY) && free(m, randX, randY) {
 			bite = NewBit(randX, randY, 50, char, BitStatic, dir, style)
 			break
 		}
@@ -176,74 +209,95 @@ func NewRandomBite(m *gamemap.GameMap, runes []rune, style tcell.Style, random b
 
 }
 
-// ExplodeBite triggers a bite explosion based on bite direction type.
-func (b *Bit) ExplodeBite(m, biteMap *gamemap.GameMap, biteExplodeRune rune, explodedStyle, defStyle tcell.Style) {
+// ExplodeBite advances a bite explosion that was triggered the given number
+// of ticks ago. The bite flashes for fuse ticks, then the explosion spreads
+// one cell every spread ticks and stays on the biteMap until linger ticks have
+// passed since the fuse ran out. It returns true once the explosion is cleared.
+func (b *Bit) ExplodeBite(tick int, m, biteMap *gamemap.GameMap, biteExplodeRune rune, explodedStyle, defStyle gamemap.Style, fuse, spread, linger int) bool {
 	b.SetStyle(explodedStyle)
-	time.Sleep(500 * time.Millisecond)
-	b.ExplodeDir(biteMap, m, biteExplodeRune, explodedStyle, true, (30 * time.Millisecond))
-	time.Sleep(10 * time.Second)
-	b.ExplodeDir(biteMap, m, ' ', defStyle, false, 0)
+	if tick < fuse {
+		return false
+	}
+	if tick < fuse+linger {
+		reach := (tick-fuse)/spread + 1
+		b.ExplodeDir(biteMap, m, biteExplodeRune, explodedStyle, true, reach)
+		return false
+	}
+	b.ExplodeDir(biteMap, m, ' ', defStyle, false, m.Width+m.Height)
+	return true
 }
 
-func (b *Bit) ExplodeDir(biteMap, m *gamemap.GameMap, char rune, style tcell.Style, blocked bool, t time.Duration) {
+// ExplodeDir sets or clears the explosion up to reach cells away from the
+// bite in each of its directions.
+func (b *Bit) ExplodeDir(biteMap, m *gamemap.GameMap, char rune, style gamemap.Style, blocked bool, reach int) {
 	if b.dir == DirUp || b.dir == DirAll {
-		go b.SetUp(biteMap, m, char, style, blocked, t)
+		b.SetUp(biteMap, m, char, style, blocked, reach)
 	}
 	if b.dir == DirDown || b.dir == DirAll {
-		go b.SetDown(biteMap, m, char, style, blocked, t)
+		b.SetDown(biteMap, m, char, style, blocked, reach)
 	}
 	if b.dir == DirLeft || b.dir == DirAll {
-		go b.SetLeft(biteMap, m, char, style, blocked, t)
+		b.SetLeft(biteMap, m, char, style, blocked, reach)
 	}
 	if b.dir == DirRight || b.dir == DirAll {
-		go b.SetRight(biteMap, m, char, style, blocked, t)
+		b.SetRight(biteMap, m, char, style, blocked, reach)
 	}
 }
 
 // SetRight sets or clears an explosion to the right.
-func (b *Bit) SetRight(biteMap, m *gamemap.GameMap, char rune, style tcell.Style, blocked bool, t time.Duration) {
-	bx, by := b.GetCurPos()
-	for x := bx + 1; x < m.Width-1; x++ {
-		time.Sleep(t)
-		SetObject(biteMap, x, by, char, style, blocked)
-	}
+func (b *Bit) SetRight(biteMap, m *gamemap.GameMap, char rune, style gamemap.Style, blocked bool, reach int) {
+	b.setRay(biteMap, m, 1, 0, char, style, blocked, reach)
 }
 
 // SetLeft sets or clears an explosion to the left.
-func (b *Bit) SetLeft(biteMap, m *gamemap.GameMap, char rune, style tcell.Style, blocked bool, t time.Duration) {
-	bx, by := b.GetCurPos()
-	for x := bx - 1; x > 1; x-- {
-		time.Sleep(t)
-		SetObject(biteMap, x, by, char, style, blocked)
-	}
+func (b *Bit) SetLeft(biteMap, m *gamemap.GameMap, char rune, style gamemap.Style, blocked bool, reach int) {
+	b.setRay(biteMap, m, -1, 0, char, style, blocked, reach)
 }
 
 // SetDown sets or clears an explosion down.
-func (b *Bit) SetDown(biteMap, m *gamemap.GameMap, char rune, style tcell.Style, blocked bool, t time.Duration) {
-	bx, by := b.GetCurPos()
-	for y := by + 1; y < m.Height-1; y++ {
-		time.Sleep(t)
-		SetObject(biteMap, bx, y, char, style, blocked)
-	}
+func (b *Bit) SetDown(biteMap, m *gamemap.GameMap, char rune, style gamemap.Style, blocked bool, reach int) {
+	b.setRay(biteMap, m, 0, 1, char, style, blocked, reach)
 }
 
 // SetUp sets or clears and explosion up.
-func (b *Bit) SetUp(biteMap, m *gamemap.GameMap, char rune, style tcell.Style, blocked bool, t time.Duration) {
+func (b *Bit) SetUp(biteMap, m *gamemap.GameMap, char rune, style gamemap.Style, blocked bool, reach int) {
+	b.setRay(biteMap, m, 0, -1, char, style, blocked, reach)
+}
+
+// setRay sets or clears an explosion up to reach cells away from the bite
+// going dx, dy at a time. It stops at the border, or if the map wraps just
+// before it comes all the way back round to the bite.
+func (b *Bit) setRay(biteMap, m *gamemap.GameMap, dx, dy int, char rune, style gamemap.Style, blocked bool, reach int) {
 	bx, by := b.GetCurPos()
-	for y := by - 1; y > 0; y-- {
-		time.Sleep(t)
-		SetObject(biteMap, bx, y, char, style, blocked)
+	size := m.Width*abs(dx) + m.Height*abs(dy)
+	for i := 1; i <= reach && i < size; i++ {
+		x, y := m.WrapPos(bx+dx*i, by+dy*i)
+		if !m.Wrap && (x < 1 || x >= m.Width-1 || y < 1 || y >= m.Height-1) {
+			break
+		}
+		SetObject(biteMap, x, y, char, style, blocked)
 	}
 }
 
 // SetObject changes the state of an object on the biteMap.
-func SetObject(biteMap *gamemap.GameMap, x, y int, char rune, style tcell.Style, blocked bool) {
+func SetObject(biteMap *gamemap.GameMap, x, y int, char rune, style gamemap.Style, blocked bool) {
 	biteMap.Objects[x][y].SetChar(char)
 	biteMap.Objects[x][y].SetStyle(style)
-	biteMap.Objects[x][y].Block()
+	if blocked {
+		biteMap.Objects[x][y].Block()
+	} else {
+		biteMap.Objects[x][y].Unblock()
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
 }
 
 // randBool generates a random boolean output.
-func randBool() bool {
-	return rand.Uint64()&(1<<63) == 0
+func randBool(r *rand.Rand) bool {
+	return r.Uint64()&(1<<63) == 0
 }
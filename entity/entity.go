@@ -1,9 +1,7 @@
 package entity
 
 import (
-	"github.com/gdamore/tcell"
 	"github.com/stjiub/gosnake/gamemap"
-	"github.com/stjiub/gosnake/style"
 )
 
 // Entity struct
@@ -11,10 +9,11 @@ type Entity struct {
 	pos       []*gamemap.Object
 	direction int
 	speed     int
+	wait      int
 }
 
 // Create a new Entity
-func NewEntity(x, y, direction, speed int, char rune, sty tcell.Style) *Entity {
+func NewEntity(x, y, direction, speed int, char rune, sty gamemap.Style) *Entity {
 	o := gamemap.NewObject(x, y, char, sty, true)
 	e := Entity{
 		direction: direction,
@@ -24,7 +23,7 @@ func NewEntity(x, y, direction, speed int, char rune, sty tcell.Style) *Entity {
 	return &e
 }
 
-func NewDisplayEntity(w, h, size, x, y int, char rune, sty tcell.Style) *Entity {
+func NewDisplayEntity(w, h, size, x, y int, char rune, sty gamemap.Style) *Entity {
 	e := NewEntity(w, h, DirAll, 0, char, sty)
 	for i := 0; i < size; i++ {
 		e.pos[i].MoveLastPos(x, y)
@@ -33,10 +32,10 @@ func NewDisplayEntity(w, h, size, x, y int, char rune, sty tcell.Style) *Entity
 	return e
 }
 
-func NewColorEntity(w, h int, char rune, colors []string, sty tcell.Style) *Entity {
+func NewColorEntity(w, h int, char rune, colors []string, sty gamemap.Style) *Entity {
 	e := NewEntity(w, h, DirAll, 0, char, sty)
 	for i := 0; i < len(colors); i++ {
-		sty := style.StringToStyle(colors[i], colors[1])
+		sty := gamemap.ColorStyle(colors[i], colors[1])
 		e.pos[i].MoveLastPos(0, 1)
 		e.pos[i].SetStyle(sty)
 		if i < len(colors)-1 {
@@ -80,7 +79,7 @@ func (e *Entity) CheckDirection() (int, int) {
 }
 
 // Add a segment to the entity
-func (e *Entity) AddSegment(num int, char rune, sty tcell.Style) {
+func (e *Entity) AddSegment(num int, char rune, sty gamemap.Style) {
 	for i := 0; i < num; i++ {
 		x, y := e.pos[len(e.pos)-1].GetLastPos()
 		o := gamemap.NewObject(x, y, char, sty, true)
@@ -98,7 +97,8 @@ func (e *Entity) RemoveSegment(num int) {
 // Check if player is blocked by an object on the map
 func (e *Entity) IsBlockedByMap(m *gamemap.GameMap, dx, dy int) bool {
 	x, y := e.pos[0].GetCurPos()
-	if m.Objects[x+dx][y+dy].IsBlocked() {
+	x, y = m.WrapPos(x+dx, y+dy)
+	if m.Objects[x][y].IsBlocked() {
 		return true
 	}
 	return false
@@ -110,7 +110,7 @@ func (e *Entity) SetChar(char rune) {
 	}
 }
 
-func (e *Entity) SetStyle(style tcell.Style) {
+func (e *Entity) SetStyle(style gamemap.Style) {
 	for i := range e.pos {
 		e.pos[i].SetStyle(style)
 	}
@@ -135,6 +135,18 @@ func (e *Entity) GetSpeed() int {
 	return e.speed
 }
 
+// Ready counts down the Entity's movement timer and reports whether the
+// Entity should move on the current tick. The Entity will then wait the
+// given number of ticks before it is ready again.
+func (e *Entity) Ready(interval int) bool {
+	if e.wait > 0 {
+		e.wait--
+		return false
+	}
+	e.wait = interval - 1
+	return true
+}
+
 func (e *Entity) GetLength() int {
 	return len(e.pos)
 }
@@ -156,6 +168,6 @@ func (e *Entity) GetChar(i int) rune {
 	return e.pos[i].GetChar()
 }
 
-func (e *Entity) GetStyle(i int) tcell.Style {
+func (e *Entity) GetStyle(i int) gamemap.Style {
 	return e.pos[i].GetStyle()
 }
@@ -1,31 +1,83 @@
 package entity
 
 import (
-	"time"
-
-	"github.com/gdamore/tcell"
 	"github.com/stjiub/gosnake/gamemap"
 )
 
-// Player item states
+// Player item effects
 const (
-	WallPass = iota
+	WallPass   = iota // Pass through walls, bites and snakes
+	SlowMo            // Move slower for finer control
+	SpeedBoost        // Move faster
+	Shield            // Survive one crash
+	Ghost             // Pass through other snakes
+	Magnet            // Pull nearby bits in
+	Shrink            // Lose some segments at once
+	Multiplier        // Score more for each bit
 )
 
+// How an effect that is activated while it is already active adds up
+const (
+	StackExtend  = iota // The new time is added to the time left
+	StackRefresh        // The time starts over
+	StackCount          // The effect gets stronger up to its Max and the time starts over
+)
+
+// Segments a Shrink item takes off, never the head
+const ShrinkSegments = 5
+
+// EffectInfo describes an item effect. Effects with no Duration happen
+// once when the item is activated.
+type EffectInfo struct {
+	Name     string // Name used in level files and shown while it is active
+	Rune     rune   // Rune the item is drawn with
+	Duration int    // Default milliseconds the effect lasts
+	Stack    int    // How the effect adds up with itself
+	Max      int    // Most times a StackCount effect adds up
+}
+
+// Effects is the registry of every item effect
+var Effects = []EffectInfo{
+	WallPass:   {Name: "wallpass", Rune: '*', Duration: 3000, Stack: StackExtend},
+	SlowMo:     {Name: "slowmo", Rune: '~', Duration: 5000, Stack: StackExtend},
+	SpeedBoost: {Name: "boost", Rune: '>', Duration: 5000, Stack: StackExtend},
+	Shield:     {Name: "shield", Rune: '=', Duration: 15000, Stack: StackRefresh},
+	Ghost:      {Name: "ghost", Rune: '%', Duration: 5000, Stack: StackExtend},
+	Magnet:     {Name: "magnet", Rune: 'U', Duration: 8000, Stack: StackRefresh},
+	Shrink:     {Name: "shrink", Rune: '-'},
+	Multiplier: {Name: "multiplier", Rune: 'x', Duration: 10000, Stack: StackCount, Max: 3},
+}
+
+// EffectByName returns the effect with the given name and whether there
+// is one.
+func EffectByName(name string) (int, bool) {
+	for i, info := range Effects {
+		if info.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Active is an item effect that is working on a player.
+type Active struct {
+	Effect    int
+	Remaining int // Ticks left
+	Count     int // Times the effect has added up
+}
+
 type Item struct {
-	effect    int
-	activated bool
-	pos       int
-	duration  time.Duration
-	ch        chan bool
+	effect   int
+	duration int
 	gamemap.Object
 }
 
-func NewItem(x, y, effect int, duration time.Duration, char rune, style tcell.Style) *Item {
+// NewItem creates an Item whose effect lasts for the given number of ticks
+// once activated.
+func NewItem(x, y, effect, duration int, char rune, style gamemap.Style) *Item {
 	i := Item{
-		effect:    effect,
-		activated: false,
-		duration:  duration,
+		effect:   effect,
+		duration: duration,
 	}
 	i.SetPos(x, y)
 	i.SetChar(char)
@@ -35,25 +87,19 @@ func NewItem(x, y, effect int, duration time.Duration, char rune, style tcell.St
 	return &i
 }
 
-func (i *Item) Activate(p *Player) {
-	switch i.effect {
-	case WallPass:
-		go i.WallPass(p)
-	}
+func (i *Item) GetEffect() int {
+	return i.effect
 }
 
-func (i *Item) WallPass(p *Player) {
-	i.activated = true
-	for end := time.Now().Add(i.duration); ; {
-		if time.Now().After(end) {
-			i.activated = false
-			p.SetChar('*')
-			p.RemoveItem(i.pos)
-			break
+// Activate starts the Item's effect on a player.
+func (i *Item) Activate(p *Player) {
+	if i.effect == Shrink {
+		n := ShrinkSegments
+		if n > p.GetLength()-1 {
+			n = p.GetLength() - 1
 		}
+		p.RemoveSegment(n)
+		return
 	}
+	p.addEffect(i.effect, i.duration)
 }
-
-// func (i *Item) SlowSpeed(p *Player) {
-// 	for end := time.Now().Add()
-// }
@@ -1,27 +1,24 @@
 package entity
 
 import (
-	"time"
-
-	"github.com/gdamore/tcell"
 	"github.com/stjiub/gosnake/gamemap"
 )
 
 // The player struct
 type Player struct {
-	name     string
-	score    int
-	count    int
-	items    []*Item
-	style    tcell.Style
-	QuitChan chan bool
-	BitChan  chan int
-	BiteChan chan int
+	name    string
+	score   int
+	count   int
+	items   []*Item
+	effects []*Active
+	style   gamemap.Style
+	dead    bool
+	decay   int
 	*Entity
 }
 
 // Make a new player
-func NewPlayer(x, y, score, direction int, char rune, name string, sty tcell.Style) *Player {
+func NewPlayer(x, y, score, direction int, char rune, name string, sty gamemap.Style) *Player {
 	e := NewEntity(x, y, direction, 1, char, sty)
 	p := Player{
 		Entity: e,
@@ -31,27 +28,39 @@ func NewPlayer(x, y, score, direction int, char rune, name string, sty tcell.Sty
 	return &p
 }
 
-func (p *Player) InitChans() {
-	p.QuitChan = make(chan bool)
-	p.BitChan = make(chan int, 1)
-	p.BiteChan = make(chan int, 1)
-}
-
 // Reset player's score and set back to middle of screen
-func (p *Player) Reset(x, y, direction int, biteExplodedStyle tcell.Style) {
-	style := p.pos[0].GetStyle()
+func (p *Player) Reset(x, y, direction int, style gamemap.Style) {
 	char := p.pos[0].GetChar()
-	p.Kill(biteExplodedStyle)
 	p.score = 0
+	p.dead = false
+	p.effects = nil
 	p.Entity = NewEntity(x, y, direction, 1, char, style)
-	p.pos[0].SetStyle(style)
 }
 
-func (p *Player) Kill(biteExplodedStyle tcell.Style) {
-	for i := range p.pos {
-		p.pos[i].SetStyle(biteExplodedStyle)
-		time.Sleep(20 * time.Millisecond)
+// Kill marks the player as dead and starts its death animation.
+func (p *Player) Kill() {
+	p.dead = true
+	p.decay = 0
+}
+
+// Decay colors the next segment of a dead player with the given style
+// and reports whether the whole body has been colored.
+func (p *Player) Decay(biteExplodedStyle gamemap.Style) bool {
+	if p.decay < len(p.pos) {
+		p.pos[p.decay].SetStyle(biteExplodedStyle)
+		p.decay++
 	}
+	return p.decay >= len(p.pos)
+}
+
+func (p *Player) IsDead() bool {
+	return p.dead
+}
+
+// IsGone reports whether a dead player's death animation has finished. A
+// player that stays gone is out of the game and no longer blocks anyone.
+func (p *Player) IsGone() bool {
+	return p.dead && p.decay >= len(p.pos)
 }
 
 // Check the position of bits in relation to the player
@@ -85,12 +94,10 @@ func (p *Player) IsBlocked(m *gamemap.GameMap, biteMap *gamemap.GameMap, entitie
 	if p.IsBlockedByMap(m, dx, dy) {
 		return true
 	}
-	for i := range p.items {
-		if p.items[i].effect == WallPass && p.items[i].activated {
-			return false
-		}
+	if p.HasEffect(WallPass) {
+		return false
 	}
-	if p.IsBlockedByPlayer(players, dx, dy) {
+	if !p.HasEffect(Ghost) && p.IsBlockedByPlayer(players, dx, dy) {
 		return true
 	}
 	if p.IsBlockedBySelf(dx, dy) {
@@ -119,16 +126,25 @@ func (p *Player) IsBlockedBySelf(dx, dy int) bool {
 
 // Check if player is blocked by another player
 func (p *Player) IsBlockedByPlayer(players []*Player, dx, dy int) bool {
+	return p.BlockingPlayer(players, dx, dy) != -1
+}
+
+// BlockingPlayer returns the index of the other player that is in the way
+// of the player's move or -1 if there is none.
+func (p *Player) BlockingPlayer(players []*Player, dx, dy int) int {
 	px, py := p.pos[0].GetCurPos()
 	for e := range players {
+		if players[e].IsGone() {
+			continue
+		}
 		for i := range players[e].pos {
 			ix, iy := players[e].pos[i].GetCurPos()
 			if px+dx == ix && py+dy == iy && !(p.name == players[e].name) {
-				return true
+				return e
 			}
 		}
 	}
-	return false
+	return -1
 }
 
 // Check if player is blocked by an entity
@@ -146,10 +162,10 @@ func (p *Player) IsBlockedByEntity(entities []*Entity, players []*Player, dx, dy
 }
 
 // Generate bits where player's body was during collision
-func (p *Player) DropBits(bits []*Bit, char rune, random int, sty tcell.Style) []*Bit {
+func (p *Player) DropBits(bits []*Bit, points int, char rune, random int, sty gamemap.Style) []*Bit {
 	for i := range p.pos {
 		ox, oy := p.pos[i].GetLastPos()
-		b := NewBit(ox, oy, 10, char, random, DirNone, sty)
+		b := NewBit(ox, oy, points, char, random, DirNone, sty)
 		bits = append(bits, b)
 	}
 	return bits
@@ -157,20 +173,18 @@ func (p *Player) DropBits(bits []*Bit, char rune, random int, sty tcell.Style) [
 
 func (p *Player) AddItem(item *Item) {
 	p.items = append(p.items, item)
-	p.AdjustItemPos()
 }
 
 func (p *Player) RemoveItem(i int) {
-	p.items[i] = p.items[len(p.items)-1]
+	copy(p.items[i:], p.items[i+1:])
 	p.items[len(p.items)-1] = nil
 	p.items = p.items[:len(p.items)-1]
-	p.AdjustItemPos()
 }
 
-func (p *Player) AdjustItemPos() {
-	for i := range p.items {
-		p.items[i].pos = i
-	}
+// GetItems returns the items the player is holding, the next to be
+// activated first.
+func (p *Player) GetItems() []*Item {
+	return p.items
 }
 
 func (p *Player) CheckItemPos(items []*Item) int {
@@ -204,6 +218,89 @@ func (p *Player) SetName(name string) {
 	p.name = name
 }
 
+// ActivateItem uses up the item the player picked up first.
 func (p *Player) ActivateItem() {
-	p.items[0].Activate(p)
+	if len(p.items) > 0 {
+		i := p.items[0]
+		p.RemoveItem(0)
+		i.Activate(p)
+	}
+}
+
+// addEffect starts an effect on the player, adding it up with the same
+// effect if it is already active.
+func (p *Player) addEffect(effect, duration int) {
+	info := Effects[effect]
+	if a := p.active(effect); a != nil {
+		switch info.Stack {
+		case StackExtend:
+			a.Remaining += duration
+		case StackRefresh:
+			a.Remaining = duration
+		case StackCount:
+			a.Remaining = duration
+			if a.Count < info.Max {
+				a.Count++
+			}
+		}
+		return
+	}
+	p.effects = append(p.effects, &Active{Effect: effect, Remaining: duration, Count: 1})
+}
+
+// active returns the player's active effect of the given kind or nil.
+func (p *Player) active(effect int) *Active {
+	for _, a := range p.effects {
+		if a.Effect == effect {
+			return a
+		}
+	}
+	return nil
+}
+
+// HasEffect reports whether an effect is active on the player.
+func (p *Player) HasEffect(effect int) bool {
+	return p.active(effect) != nil
+}
+
+// EffectCount returns how many times an effect has added up on the player,
+// 0 if it is not active.
+func (p *Player) EffectCount(effect int) int {
+	if a := p.active(effect); a != nil {
+		return a.Count
+	}
+	return 0
+}
+
+// UseEffect ends an effect that only works once, like a shield.
+func (p *Player) UseEffect(effect int) {
+	for i, a := range p.effects {
+		if a.Effect == effect {
+			p.effects = append(p.effects[:i], p.effects[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetEffects returns the effects active on the player in the order they
+// started.
+func (p *Player) GetEffects() []Active {
+	var effects []Active
+	for _, a := range p.effects {
+		effects = append(effects, *a)
+	}
+	return effects
+}
+
+// StepItems counts down the player's active effects by one tick and ends
+// the ones that have run out.
+func (p *Player) StepItems() {
+	var effects []*Active
+	for _, a := range p.effects {
+		a.Remaining--
+		if a.Remaining > 0 {
+			effects = append(effects, a)
+		}
+	}
+	p.effects = effects
 }
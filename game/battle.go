@@ -0,0 +1,106 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Battle mode rules
+const (
+	BattleLives      = 3
+	BattleRounds     = 3
+	BattleRoundTime  = 2 * time.Minute
+	BattleDropPoints = 25
+)
+
+// MenuMode lets the players pick the game mode. Modes are only offered if
+// there are enough players for them.
+func (g *Game) MenuMode() int {
+	g.gview.Clear()
+	renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+	renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
+	var options []string
+	var modes []int
+	for i, m := range gameModes {
+		if g.numPlayers >= minPlayers[m] {
+			options = append(options, gameModeOptions[i])
+			modes = append(modes, m)
+		}
+	}
+	i := g.handleMenu(options)
+	if i == ItemExit {
+		return MenuPlayer
+	}
+	g.mode = modes[i]
+	return MenuProfile
+}
+
+// battleConfig adds the battle rules to an engine config.
+func battleConfig(config *engine.Config) {
+	config.Lives = BattleLives
+	config.Rounds = BattleRounds
+	config.RoundTime = BattleRoundTime
+	config.DropPoints = BattleDropPoints
+}
+
+// renderBattle draws each player's score, lives and kills, the round and
+// its timer, and the scoreboard during the break between rounds.
+func renderBattle(g *Game, s *engine.Snapshot) {
+	w, h := g.viewWidth, g.viewHeight
+	round := fmt.Sprintf("round %v/%v - %v", s.Round, s.Rounds, clock(s.TimeLeft))
+	renderCenterStr(g.hview, w, h-2, g.SelStyle, round)
+	for i, p := range s.Players {
+		lines := []string{
+			fmt.Sprintf("%v: %v", p.GetName(), p.GetScore()),
+			fmt.Sprintf("lives %v - kills %v", s.Stats[i].Lives, s.Stats[i].RoundKills),
+		}
+		for j, l := range lines {
+			x := (w*(2*i+1))/(2*len(s.Players)) - (len(l) / 2)
+			renderStr(g.hview, x, h/2+j, g.SelStyle, l)
+		}
+	}
+	if s.Break > 0 {
+		renderScoreboard(g, s)
+	}
+}
+
+// renderScoreboard draws the winner of the round and the match stats of
+// every player.
+func renderScoreboard(g *Game, s *engine.Snapshot) {
+	title := fmt.Sprintf("Round %v of %v - draw", s.Round, s.Rounds)
+	if s.Winner >= 0 {
+		title = fmt.Sprintf("Round %v of %v - %v wins", s.Round, s.Rounds, s.Players[s.Winner].GetName())
+	}
+	rows := []string{title, "", fmt.Sprintf("%-12v%7v%7v%7v", "", "kills", "deaths", "wins")}
+	for i, p := range s.Players {
+		st := s.Stats[i]
+		rows = append(rows, fmt.Sprintf("%-12.12v%7v%7v%7v", p.GetName(), st.Kills, st.Deaths, st.Wins))
+	}
+	if s.Round == s.Rounds {
+		rows = append(rows, "", "Final scores")
+	}
+	renderBox(g, rows)
+}
+
+// renderBox clears a box in the middle of the screen and draws the rows
+// centered in it.
+func renderBox(g *Game, rows []string) {
+	bw, bh := 40, len(rows)+2
+	bx, by := (g.viewWidth-bw)/2, (g.viewHeight-bh)/2
+	for y := by; y < by+bh; y++ {
+		for x := bx; x < bx+bw; x++ {
+			renderRune(g.hview, x, y, g.DefStyle, ' ')
+		}
+	}
+	for i, r := range rows {
+		renderStr(g.hview, (g.viewWidth-len(r))/2, by+1+i, g.SelStyle, r)
+	}
+}
+
+// clock formats a number of ticks as minutes and seconds.
+func clock(ticks int) string {
+	d := time.Duration(ticks) * engine.TickDuration
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell"
+	"github.com/gdamore/tcell/views"
+	"github.com/stjiub/gosnake/engine"
+	"github.com/stjiub/gosnake/entity"
+)
+
+// Cells kept on screen around the players by the shared camera
+const cameraMargin = 8
+
+// zoomView draws onto a ViewPort at 1/zoom scale. Blank cells never
+// overwrite something already drawn in the same frame so that bits and
+// snakes stay visible when zoomed out.
+type zoomView struct {
+	*views.ViewPort
+	zoom  int
+	drawn map[[2]int]bool
+}
+
+func newZoomView(v *views.ViewPort, zoom int) *zoomView {
+	z := zoomView{
+		ViewPort: v,
+		zoom:     zoom,
+		drawn:    make(map[[2]int]bool),
+	}
+	return &z
+}
+
+// SetContent draws a map cell into the screen cell that covers it.
+func (z *zoomView) SetContent(x, y int, ch rune, comb []rune, s tcell.Style) {
+	x, y = x/z.zoom, y/z.zoom
+	if ch == ' ' && z.drawn[[2]int{x, y}] {
+		return
+	}
+	if ch != ' ' {
+		z.drawn[[2]int{x, y}] = true
+	}
+	z.ViewPort.SetContent(x, y, ch, comb, s)
+}
+
+// SetCamera sets how two or more players share the screen. A "shared"
+// camera zooms out to keep every player on screen and "split" gives each
+// player their own view.
+func (g *Game) SetCamera(camera string) error {
+	switch camera {
+	case "shared":
+		g.camera = CameraShared
+	case "split":
+		g.camera = CameraSplit
+	default:
+		return fmt.Errorf("camera must be shared or split, got %q", camera)
+	}
+	return nil
+}
+
+// resetCamera shows the top left of the map in the game view at full scale.
+func (g *Game) resetCamera() {
+	g.gview.SetContentSize(g.mapWidth, g.mapHeight, true)
+	g.gview.ScrollLeft(g.mapWidth)
+	g.gview.ScrollUp(g.mapHeight)
+}
+
+// layoutSplit creates one view per player for the split camera. Two
+// players are side by side and three or four players share a 2x2 grid.
+func (g *Game) layoutSplit() {
+	g.pviews = nil
+	if g.camera != CameraSplit || g.numPlayers < 2 {
+		return
+	}
+	cols, rows := g.numPlayers, 1
+	if g.numPlayers > 2 {
+		cols, rows = 2, 2
+	}
+	pw := (g.viewWidth - (cols - 1)) / cols
+	ph := (g.viewHeight - (rows - 1)) / rows
+	for i := 0; i < g.numPlayers; i++ {
+		x, y := g.viewX+(i%cols)*(pw+1), g.viewY+(i/cols)*(ph+1)
+		v := views.NewViewPort(g.screen, x, y, pw, ph)
+		v.SetContentSize(g.mapWidth, g.mapHeight, true)
+		g.pviews = append(g.pviews, v)
+	}
+}
+
+// sharedCamera returns the zoom and the map position to center on so that
+// every player is on screen. The camera only zooms out when the map does not
+// already fit in the view.
+func sharedCamera(players []*entity.Player, mapWidth, mapHeight, w, h int) (int, int, int) {
+	// Players that are out of the game are not followed
+	var active []*entity.Player
+	for _, p := range players {
+		if !p.IsGone() {
+			active = append(active, p)
+		}
+	}
+	if len(active) > 0 {
+		players = active
+	}
+	if len(players) == 0 {
+		return 1, mapWidth / 2, mapHeight / 2
+	}
+	minX, minY := players[0].GetCurPos(0)
+	maxX, maxY := minX, minY
+	for _, p := range players[1:] {
+		x, y := p.GetCurPos(0)
+		if x < minX {
+			minX = x
+		}
+		if x > maxX {
+			maxX = x
+		}
+		if y < minY {
+			minY = y
+		}
+		if y > maxY {
+			maxY = y
+		}
+	}
+	spanX := maxX - minX + cameraMargin*2
+	spanY := maxY - minY + cameraMargin*2
+
+	zoom := 1
+	for {
+		mapFits := (mapWidth+zoom-1)/zoom <= w && (mapHeight+zoom-1)/zoom <= h
+		playersFit := spanX/zoom < w && spanY/zoom < h
+		if mapFits || playersFit {
+			break
+		}
+		zoom++
+	}
+	return zoom, (minX + maxX) / 2, (minY + maxY) / 2
+}
+
+// renderCamera draws the match through the camera. The single game view
+// follows the players, zooming out if needed, unless the split camera is
+// used in which case each player's view follows them.
+func renderCamera(g *Game, s *engine.Snapshot) {
+	if len(g.pviews) > 0 && len(s.Players) >= len(g.pviews) {
+		g.gview.Clear()
+		for i, v := range g.pviews {
+			x, y := s.Players[i].GetCurPos(0)
+			v.Center(x, y)
+			v.Clear()
+			renderWorld(v, &g.Style, s)
+
+			// Separate the views with lines
+			x1, y1, x2, y2 := v.GetPhysical()
+			x1, y1, x2, y2 = x1-g.viewX, y1-g.viewY, x2-g.viewX, y2-g.viewY
+			if x1 > 0 {
+				for y := y1; y <= y2; y++ {
+					renderRune(g.hview, x1-1, y, g.DefStyle, '│')
+				}
+			}
+			if y1 > 0 {
+				for x := x1; x <= x2; x++ {
+					renderRune(g.hview, x, y1-1, g.DefStyle, '─')
+				}
+			}
+		}
+		return
+	}
+
+	zoom, x, y := sharedCamera(s.Players, s.Map.Width, s.Map.Height, g.viewWidth, g.viewHeight)
+	g.gview.SetContentSize((s.Map.Width+zoom-1)/zoom, (s.Map.Height+zoom-1)/zoom, true)
+	g.gview.Center(x/zoom, y/zoom)
+	g.gview.Clear()
+	if zoom > 1 {
+		renderWorld(newZoomView(g.gview, zoom), &g.Style, s)
+	} else {
+		renderWorld(g.gview, &g.Style, s)
+	}
+}
@@ -0,0 +1,145 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Lives a snake has on each campaign stage. A stage earns one star for
+// every life left when it is cleared.
+const CampaignLives = 3
+
+// MenuStage lets the player pick a campaign stage. A stage is unlocked once
+// the one before it has been cleared.
+func (g *Game) MenuStage() int {
+	g.loadStages()
+	p := g.curProfiles[0]
+	for {
+		var options []string
+		for i, l := range g.stages {
+			if g.unlocked(p, i) {
+				options = append(options, fmt.Sprintf("%v. %-16v %v", i+1, l.Name, stars(p.Campaign[l.Name])))
+			} else {
+				options = append(options, fmt.Sprintf("%v. %-16v %v", i+1, l.Name, "locked"))
+			}
+		}
+		g.gview.Clear()
+		renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+		renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
+		i := g.handleMenu(options)
+		if i == ItemExit {
+			g.state = MainMenu
+			return MenuMain
+		}
+		if g.unlocked(p, i) {
+			g.stage = i
+			return MenuMain
+		}
+	}
+}
+
+// loadStages reads the campaign stages, falling back to the built in
+// campaign.
+func (g *Game) loadStages() {
+	stages, err := engine.LoadCampaign(g.campaignDir, g.mapWidth, g.mapHeight)
+	if err != nil {
+		logger.Errorf("Error loading campaign, using default campaign: %v", err)
+		stages = engine.DefaultCampaign()
+	}
+	g.stages = stages
+}
+
+// unlocked reports whether a profile can play stage i.
+func (g *Game) unlocked(p *Profile, i int) bool {
+	return i == 0 || p.Campaign[g.stages[i-1].Name] > 0
+}
+
+// campaignConfig adds the campaign rules to an engine config. Stages place
+// their own bits.
+func campaignConfig(config *engine.Config) {
+	config.Lives = CampaignLives
+	config.NumBits = 0
+}
+
+// clearStage saves the stars earned on the current stage to the player's
+// profile if they are better than before.
+func (g *Game) clearStage(earned int) {
+	name := g.stages[g.stage].Name
+	cur := g.curProfiles[0]
+	cur.setStars(name, earned)
+	g.profiles = DecodeProfiles(ReadFile(g.proFile))
+	for _, p := range g.profiles {
+		if p.Name == cur.Name {
+			p.setStars(name, earned)
+		}
+	}
+	WriteProfiles(g.profiles, g.proFile)
+	logger.Infof("%v cleared %v with %v stars", cur.Name, name, earned)
+}
+
+// setStars records the stars earned on a stage if they are more than the
+// profile had before.
+func (p *Profile) setStars(stage string, n int) {
+	if p.Campaign[stage] >= n {
+		return
+	}
+	if p.Campaign == nil {
+		p.Campaign = make(map[string]int)
+	}
+	p.Campaign[stage] = n
+}
+
+// renderCampaign draws the stage, the player's score and lives and how far
+// they are from the objective, and the result once the stage is over.
+func renderCampaign(g *Game, s *engine.Snapshot) {
+	w, h := g.viewWidth, g.viewHeight
+	if g.stage >= len(g.stages) {
+		return
+	}
+	l := g.stages[g.stage]
+	lives := 0
+	if len(s.Stats) > 0 {
+		lives = s.Stats[0].Lives
+	}
+	stage := fmt.Sprintf("stage %v/%v: %v - lives %v", g.stage+1, len(g.stages), l.Name, lives)
+	renderCenterStr(g.hview, w, h-2, g.SelStyle, stage)
+	renderScore(g.hview, s.Players, w, h, g.SelStyle)
+
+	if o := l.Objective; o != nil {
+		var parts []string
+		if o.Score > 0 {
+			parts = append(parts, fmt.Sprintf("score %v/%v", s.Players[0].GetScore(), o.Score))
+		}
+		if o.Survive > 0 {
+			total := int(time.Duration(o.Survive) * time.Second / engine.TickDuration)
+			parts = append(parts, fmt.Sprintf("time %v/%v", clock(s.Elapsed), clock(total)))
+		}
+		if o.AllBits {
+			parts = append(parts, fmt.Sprintf("bits left %v", len(s.Bits)))
+		}
+		renderCenterStr(g.hview, w, h/2+1, g.SelStyle, strings.Join(parts, " - "))
+	}
+
+	if s.Break > 0 {
+		if s.Winner >= 0 {
+			renderBox(g, []string{"Stage cleared!", "", stars(lives)})
+		} else {
+			renderBox(g, []string{"Stage failed", "", "Out of lives"})
+		}
+	}
+}
+
+// stars draws a star rating out of CampaignLives. Ratings outside of that
+// range, which an edited profiles file can hold, are clamped to it.
+func stars(n int) string {
+	if n < 0 {
+		n = 0
+	} else if n > CampaignLives {
+		n = CampaignLives
+	}
+	return strings.Repeat("★", n) + strings.Repeat("☆", CampaignLives-n)
+}
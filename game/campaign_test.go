@@ -0,0 +1,21 @@
+package game
+
+import "testing"
+
+func TestStars(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{-1, "☆☆☆"},
+		{0, "☆☆☆"},
+		{2, "★★☆"},
+		{3, "★★★"},
+		{5, "★★★"},
+	}
+	for _, tt := range tests {
+		if got := stars(tt.n); got != tt.want {
+			t.Errorf("stars(%v) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Co-op mode rules
+const (
+	CoopLives    = 5
+	CoopGoalTime = 20 * time.Second
+)
+
+// coopConfig adds the co-op rules to an engine config. Teammates can pass
+// through each other if it is turned on in the settings.
+func coopConfig(config *engine.Config, s *Settings) {
+	config.TeamLives = CoopLives
+	config.PassThrough = s.PassThrough
+	config.GoalTime = CoopGoalTime
+}
+
+// teamName returns the names of the players in the team in alphabetical
+// order so the same team always gets the same entry in the high scores.
+func (g *Game) teamName() string {
+	var names []string
+	for i := 0; i < g.numPlayers; i++ {
+		names = append(names, g.curProfiles[i].Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " & ")
+}
+
+// renderCoop draws the level, the team's score and lives, and the time left
+// to meet the current goal along with who still has to eat part of it.
+func renderCoop(g *Game, s *engine.Snapshot) {
+	w, h := g.viewWidth, g.viewHeight
+	renderLevel(g.hview, s.Level, w, h, g.SelStyle)
+	team := fmt.Sprintf("team: %v - lives %v", s.TeamScore, s.TeamLives)
+	renderCenterStr(g.hview, w, h/2, g.SelStyle, team)
+	if s.GoalLeft > 0 {
+		goal := "goal: " + clock(s.GoalLeft)
+		for i, eaten := range s.GoalEaten {
+			if !eaten {
+				goal += " - " + s.Players[i].GetName()
+			}
+		}
+		renderCenterStr(g.hview, w, h/2+1, g.SelStyle, goal)
+	}
+}
@@ -0,0 +1,168 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Daily challenge rules
+const (
+	DailyDays  = 365          // Days of daily challenge scores kept in the score file
+	dateFormat = "2006-01-02" // Format of daily challenge dates
+)
+
+// Modes the rules of a daily challenge are picked from
+var dailyModes = []int{Basic, Advanced, TimeAttack, Survival}
+
+// today returns the date of today's daily challenge.
+func today() string {
+	return time.Now().Format(dateFormat)
+}
+
+// addDays returns the date n days after date.
+func addDays(date string, n int) string {
+	t, err := time.Parse(dateFormat, date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, n).Format(dateFormat)
+}
+
+// dailySeed returns the seed everyone plays with on a date.
+func dailySeed(date string) int64 {
+	t, _ := time.Parse(dateFormat, date)
+	return int64(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
+
+// dailyRules returns the mode whose rules are played with a daily seed.
+func dailyRules(seed int64) int {
+	return dailyModes[rand.New(rand.NewSource(seed)).Intn(len(dailyModes))]
+}
+
+// rules returns the mode whose rules are played. The daily challenge plays
+// the rules picked by its seed.
+func (g *Game) rules() int {
+	if g.mode == Daily {
+		return dailyRules(g.seed)
+	}
+	return g.mode
+}
+
+// dailyConfig plays the daily challenge with the default settings so every
+// player gets the same board.
+func dailyConfig(config *engine.Config) {
+	s := DefaultSettings()
+	config.Width, config.Height = DefaultMapWidth, DefaultMapHeight
+	config.NumBits = s.NumBits
+	config.HorizontalMove, config.VerticalMove = s.moveTimes()
+	config.Wrap = s.Wrap
+	config.OpenBorder = s.OpenBorder
+}
+
+// MenuDaily shows today's challenge to the player who picked it. Each
+// profile only gets one attempt a day.
+func (g *Game) MenuDaily() int {
+	g.getScores()
+	name := g.curProfiles[0].Name
+	g.date = today()
+	g.seed = dailySeed(g.date)
+
+	g.gview.Clear()
+	renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+	renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
+	renderCenterStr(g.gview, g.viewWidth, g.viewHeight-6, g.DefStyle, "Daily Challenge "+g.date+" - "+modeNames[g.rules()])
+	renderCenterStr(g.gview, g.viewWidth, g.viewHeight-5, g.DefStyle, fmt.Sprintf("%v day streak", streak(g.scores[Daily], name, g.date)))
+
+	if dailyScore(g.scores[Daily], name, g.date) != nil {
+		renderCenterStr(g.gview, g.viewWidth, g.viewHeight-4, g.SelStyle, "Already played today, come back tomorrow")
+		g.handleMenu([]string{"Back"})
+		g.state = MainMenu
+		return MenuMain
+	}
+	if g.handleMenu([]string{"Play"}) == ItemExit {
+		g.state = MainMenu
+	}
+	return MenuMain
+}
+
+// recordDaily keeps a player's score for the day's challenge. The first
+// score of the day is recorded when the attempt starts so that quitting
+// still uses it up.
+func (g *Game) recordDaily(name string, score int) {
+	g.getScores()
+	table := g.scores[Daily]
+	if s := dailyScore(table, name, g.date); s != nil {
+		if score <= s.Score {
+			return
+		}
+		s.Score = score
+	} else {
+		s := NewScore(name, Daily, score)
+		s.Date = g.date
+		table = AddScore(table, s)
+	}
+
+	// Forget challenges from too long ago
+	oldest := addDays(g.date, -DailyDays)
+	var kept []*Score
+	for _, s := range table {
+		if s.Date > oldest {
+			kept = append(kept, s)
+		}
+	}
+	g.scores[Daily] = kept
+	WriteScores(g.scores, g.scoreFile)
+}
+
+// dailyScore returns a player's score for a day's challenge or nil if they
+// have not played it.
+func dailyScore(scores []*Score, name, date string) *Score {
+	for _, s := range scores {
+		if s.Name == name && s.Date == date {
+			return s
+		}
+	}
+	return nil
+}
+
+// dailyScores returns the best scores of a day's challenge.
+func dailyScores(scores []*Score, date string) []*Score {
+	var day []*Score
+	for _, s := range scores {
+		if s.Date == date {
+			day = append(day, s)
+		}
+	}
+	sort.SliceStable(day, func(i, j int) bool { return day[i].Score > day[j].Score })
+	return RemoveScores(day, MaxHighScores)
+}
+
+// streak returns the number of days in a row a player has played the daily
+// challenge up to date. A streak is not broken until a whole day is missed.
+func streak(scores []*Score, name, date string) int {
+	if dailyScore(scores, name, date) == nil {
+		date = addDays(date, -1)
+	}
+	n := 0
+	for dailyScore(scores, name, date) != nil {
+		n++
+		date = addDays(date, -1)
+	}
+	return n
+}
+
+// renderDailyScores draws the best scores of a day's challenge along with
+// each player's streak.
+func renderDailyScores(g *Game, style tcell.Style, date string, y int) {
+	table := g.scores[Daily]
+	renderCenterStr(g.gview, g.viewWidth, y-2, style, date+" - "+modeNames[dailyRules(dailySeed(date))])
+	for i, s := range dailyScores(table, date) {
+		line := fmt.Sprintf("%v - %v - %v day streak", s.Name, s.Score, streak(table, s.Name, date))
+		renderCenterStr(g.gview, g.viewWidth, y+i*2, g.SelStyle, line)
+	}
+}
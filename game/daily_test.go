@@ -0,0 +1,83 @@
+package game
+
+import "testing"
+
+func TestDailySeed(t *testing.T) {
+	tests := []struct {
+		date string
+		seed int64
+	}{
+		{"2026-10-18", 20261018},
+		{"2026-12-31", 20261231},
+		{"2027-01-01", 20270101},
+		{"2028-02-29", 20280229},
+	}
+	for _, test := range tests {
+		if seed := dailySeed(test.date); seed != test.seed {
+			t.Errorf("got seed %v for %v, want %v", seed, test.date, test.seed)
+		}
+		rules := dailyRules(dailySeed(test.date))
+		if rules != dailyRules(test.seed) {
+			t.Errorf("%v: the same seed picked different rules", test.date)
+		}
+		found := false
+		for _, m := range dailyModes {
+			found = found || m == rules
+		}
+		if !found {
+			t.Errorf("%v: got rules of mode %v, want one of %v", test.date, rules, dailyModes)
+		}
+	}
+}
+
+func TestAddDays(t *testing.T) {
+	tests := []struct {
+		date string
+		n    int
+		want string
+	}{
+		{"2026-10-18", 1, "2026-10-19"},
+		{"2026-10-31", 1, "2026-11-01"},
+		{"2026-12-31", 1, "2027-01-01"},
+		{"2027-01-01", -1, "2026-12-31"},
+		{"2028-03-01", -1, "2028-02-29"},
+		{"2026-10-18", -DailyDays, "2025-10-18"},
+		{"not a date", 1, "not a date"},
+	}
+	for _, test := range tests {
+		if got := addDays(test.date, test.n); got != test.want {
+			t.Errorf("%v plus %v days: got %v, want %v", test.date, test.n, got, test.want)
+		}
+	}
+}
+
+func TestStreak(t *testing.T) {
+	played := func(name string, dates ...string) []*Score {
+		var scores []*Score
+		for _, d := range dates {
+			s := NewScore(name, Daily, 10)
+			s.Date = d
+			scores = append(scores, s)
+		}
+		return scores
+	}
+	tests := []struct {
+		name   string
+		scores []*Score
+		date   string
+		want   int
+	}{
+		{"never played", nil, "2026-10-18", 0},
+		{"today", played("a", "2026-10-18"), "2026-10-18", 1},
+		{"not yet today", played("a", "2026-10-16", "2026-10-17"), "2026-10-18", 2},
+		{"missed yesterday", played("a", "2026-10-16"), "2026-10-18", 0},
+		{"gap", played("a", "2026-10-14", "2026-10-16", "2026-10-17", "2026-10-18"), "2026-10-18", 3},
+		{"someone else", played("b", "2026-10-17", "2026-10-18"), "2026-10-18", 0},
+		{"new year", played("a", "2026-12-30", "2026-12-31", "2027-01-01"), "2027-01-01", 3},
+	}
+	for _, test := range tests {
+		if got := streak(test.scores, "a", test.date); got != test.want {
+			t.Errorf("%v: got a %v day streak, want %v", test.name, got, test.want)
+		}
+	}
+}
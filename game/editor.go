@@ -0,0 +1,632 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/engine"
+	"github.com/stjiub/gosnake/entity"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+var (
+	editorControls string = "tab = tool - r = direction - +/- = length - space = place - x = erase - ^z/^y = undo/redo - t = test - ^s = save"
+	editorTools           = []string{"wall", "floor", "spawn", "bit spawn", "bit line", "bite", "item", "moving wall", "portal"}
+	editorDirs            = []string{"up", "down", "left", "right", "all"}
+)
+
+// Editor stores a level while it is being edited. The map is edited as a
+// layout grid the size of the map and everything else is kept in the level
+// at its position on the grid. Positions are converted to the form they
+// are saved in by Level.
+type Editor struct {
+	level   *engine.Level // Level being edited
+	grid    [][]rune      // Layout tiles indexed by [y][x]
+	file    string        // File the level is saved to
+	x, y    int           // Cursor position
+	tool    int           // Selected tool
+	dir     int           // Selected direction for bit lines, bites and moving walls
+	length  int           // Selected length for bit lines and moving walls
+	undo    [][]byte      // Encoded levels to go back to
+	redo    [][]byte      // Encoded levels that were undone
+	changed bool          // Whether there are unsaved changes
+	message string        // Message shown in place of the controls
+}
+
+// NewEditor creates an Editor for a level on a map of the given size.
+func NewEditor(l *engine.Level, file string, w, h int) *Editor {
+	ed := Editor{
+		level:  l,
+		file:   file,
+		x:      w / 2,
+		y:      h / 2,
+		dir:    entity.DirRight,
+		length: 5,
+	}
+	ed.setGrid(w, h)
+	return &ed
+}
+
+// setGrid builds the layout grid from the level.
+func (ed *Editor) setGrid(w, h int) {
+	l := ed.level
+	ed.grid = make([][]rune, h)
+	for y := range ed.grid {
+		ed.grid[y] = make([]rune, w)
+		for x := range ed.grid[y] {
+			if x == 0 || y == 0 || x == w-1 || y == h-1 {
+				ed.grid[y][x] = gamemap.TileWall
+			} else {
+				ed.grid[y][x] = gamemap.TileFloor
+			}
+		}
+	}
+
+	// Layouts are placed in the middle of the map the same way the engine does
+	oy := (h - len(l.Layout)) / 2
+	for y, row := range l.Layout {
+		r := []rune(row)
+		ox := (w - len(r)) / 2
+		for x, c := range r {
+			if c == ' ' {
+				c = gamemap.TileFloor
+			}
+			if ox+x >= 0 && ox+x < w && oy+y >= 0 && oy+y < h {
+				ed.grid[oy+y][ox+x] = c
+			}
+		}
+	}
+	positions(l, func(x, y *float64) {
+		cx, cy := l.Pos(*x, *y, w, h)
+		*x, *y = float64(cx), float64(cy)
+	})
+	l.FromLayout = false
+	l.LayoutFile = ""
+}
+
+// Level returns a copy of the edited level with the grid stored as its
+// layout. The border is left out, and so are the floor rows and columns
+// around the edges, the same number on each side so the layout stays in the
+// middle. Everything else is placed from the corner of the layout, or from
+// the nearest edge of the map if there is no layout. This lets the level be
+// played on maps of other sizes.
+func (ed *Editor) Level() *engine.Level {
+	w, h := len(ed.grid[0]), len(ed.grid)
+	mx, my := w, h
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			if ed.grid[y][x] == gamemap.TileFloor {
+				continue
+			}
+			for _, m := range []int{x - 1, w - 2 - x} {
+				if m < mx {
+					mx = m
+				}
+			}
+			for _, m := range []int{y - 1, h - 2 - y} {
+				if m < my {
+					my = m
+				}
+			}
+		}
+	}
+	l := *ed.level
+	l.Layout = nil
+	if mx < w {
+		for y := 1 + my; y < h-1-my; y++ {
+			l.Layout = append(l.Layout, string(ed.grid[y][1+mx:w-1-mx]))
+		}
+	}
+	l.FromLayout = len(l.Layout) > 0
+	positions(&l, func(x, y *float64) {
+		*x, *y = l.LevelPos(int(*x), int(*y), w, h)
+	})
+	return &l
+}
+
+// positions calls f with a pointer to every position in l. The lists in l
+// are copied first so the level they came from is not changed.
+func positions(l *engine.Level, f func(x, y *float64)) {
+	l.Walls = append([]engine.Rect(nil), l.Walls...)
+	for i := range l.Walls {
+		f(&l.Walls[i].X, &l.Walls[i].Y)
+	}
+	l.MovingWalls = append([]engine.MovingWall(nil), l.MovingWalls...)
+	for i := range l.MovingWalls {
+		f(&l.MovingWalls[i].X, &l.MovingWalls[i].Y)
+	}
+	l.Items = append([]engine.ItemSpawn(nil), l.Items...)
+	for i := range l.Items {
+		f(&l.Items[i].X, &l.Items[i].Y)
+	}
+	l.BitLines = append([]engine.BitLine(nil), l.BitLines...)
+	for i := range l.BitLines {
+		f(&l.BitLines[i].X, &l.BitLines[i].Y)
+	}
+	l.StaticBites = append([]engine.StaticBite(nil), l.StaticBites...)
+	for i := range l.StaticBites {
+		f(&l.StaticBites[i].X, &l.StaticBites[i].Y)
+	}
+}
+
+// cell returns the grid cell of a position in the edited level.
+func (ed *Editor) cell(x, y float64) (int, int) {
+	return int(x), int(y)
+}
+
+// save records the current level so the next change can be undone.
+func (ed *Editor) save() {
+	ed.undo = append(ed.undo, engine.EncodeLevel(ed.Level()))
+	ed.redo = nil
+}
+
+// restore moves the editor back to a recorded level.
+func (ed *Editor) restore(data []byte) {
+	l, err := engine.DecodeLevel(data)
+	if err != nil {
+		logger.Errorf("Error restoring level: %v", err)
+		return
+	}
+	ed.level = l
+	ed.setGrid(len(ed.grid[0]), len(ed.grid))
+}
+
+// Undo reverts the last change.
+func (ed *Editor) Undo() {
+	if len(ed.undo) == 0 {
+		return
+	}
+	ed.redo = append(ed.redo, engine.EncodeLevel(ed.Level()))
+	ed.restore(ed.undo[len(ed.undo)-1])
+	ed.undo = ed.undo[:len(ed.undo)-1]
+	ed.changed = true
+}
+
+// Redo applies the last change that was undone.
+func (ed *Editor) Redo() {
+	if len(ed.redo) == 0 {
+		return
+	}
+	ed.undo = append(ed.undo, engine.EncodeLevel(ed.Level()))
+	ed.restore(ed.redo[len(ed.redo)-1])
+	ed.redo = ed.redo[:len(ed.redo)-1]
+	ed.changed = true
+}
+
+// Place uses the selected tool at the cursor. Changes that would make the
+// level invalid are reverted.
+func (ed *Editor) Place() {
+	w, h := len(ed.grid[0]), len(ed.grid)
+	x, y := ed.x, ed.y
+	if x < 1 || y < 1 || x > w-2 || y > h-2 {
+		return
+	}
+	if _, ok := ed.nextPortal(); ed.tool == ToolPortal && !ok {
+		ed.message = "Every portal is used"
+		return
+	}
+
+	ed.save()
+	ed.erase(x, y)
+	l := ed.level
+	px, py := float64(x), float64(y)
+	switch ed.tool {
+	case ToolWall:
+		ed.grid[y][x] = gamemap.TileWall
+	case ToolSpawn:
+		ed.grid[y][x] = gamemap.TileSpawn
+	case ToolBitSpawn:
+		ed.grid[y][x] = gamemap.TileBit
+	case ToolBitLine:
+		dir := "right"
+		if ed.dir == entity.DirUp || ed.dir == entity.DirDown {
+			dir = "down"
+		}
+		l.BitLines = append(l.BitLines, engine.BitLine{X: px, Y: py, Direction: dir, Length: ed.length})
+	case ToolBite:
+		l.StaticBites = append(l.StaticBites, engine.StaticBite{X: px, Y: py, Direction: editorDirs[ed.dir]})
+	case ToolItem:
+		l.Items = append(l.Items, engine.ItemSpawn{X: px, Y: py, Effect: "wallpass", Duration: engine.DefaultItemDuration})
+	case ToolMovingWall:
+		dir := ed.dir
+		if dir == entity.DirAll {
+			dir = entity.DirRight
+		}
+		l.MovingWalls = append(l.MovingWalls, engine.MovingWall{X: px, Y: py, Direction: editorDirs[dir], Speed: 1, Segments: ed.length})
+	case ToolPortal:
+		ed.grid[y][x], _ = ed.nextPortal()
+	}
+
+	if err := ed.Level().Validate(w, h); err != nil {
+		ed.restore(ed.undo[len(ed.undo)-1])
+		ed.undo = ed.undo[:len(ed.undo)-1]
+		ed.message = err.Error()
+		return
+	}
+	ed.changed = true
+	if c := ed.grid[y][x]; ed.tool == ToolPortal && ed.portalCount(c) == 1 {
+		ed.message = fmt.Sprintf("Place the other end of portal %c", c)
+	}
+}
+
+// nextPortal returns the portal tile to place next, finishing a pair that
+// only has one end before starting a new one.
+func (ed *Editor) nextPortal() (rune, bool) {
+	for _, c := range gamemap.TilePortals {
+		if ed.portalCount(c) == 1 {
+			return c, true
+		}
+	}
+	for _, c := range gamemap.TilePortals {
+		if ed.portalCount(c) == 0 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// portalCount returns how many tiles of a portal are on the grid.
+func (ed *Editor) portalCount(c rune) int {
+	n := 0
+	for _, row := range ed.grid {
+		for _, t := range row {
+			if t == c {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Erase removes everything at the cursor.
+func (ed *Editor) Erase() {
+	tool := ed.tool
+	ed.tool = ToolFloor
+	ed.Place()
+	ed.tool = tool
+}
+
+// erase sets a cell to floor and removes everything that starts on it and
+// any static wall that covers it.
+func (ed *Editor) erase(x, y int) {
+	l := ed.level
+	ed.grid[y][x] = gamemap.TileFloor
+	at := func(lx, ly float64) bool {
+		cx, cy := ed.cell(lx, ly)
+		return cx == x && cy == y
+	}
+
+	var rects []engine.Rect
+	for _, r := range l.Walls {
+		if rx, ry := ed.cell(r.X, r.Y); x < rx || x >= rx+r.W || y < ry || y >= ry+r.H {
+			rects = append(rects, r)
+		}
+	}
+	l.Walls = rects
+
+	var items []engine.ItemSpawn
+	for _, i := range l.Items {
+		if !at(i.X, i.Y) {
+			items = append(items, i)
+		}
+	}
+	l.Items = items
+
+	var lines []engine.BitLine
+	for _, b := range l.BitLines {
+		if !at(b.X, b.Y) {
+			lines = append(lines, b)
+		}
+	}
+	l.BitLines = lines
+
+	var bites []engine.StaticBite
+	for _, b := range l.StaticBites {
+		if !at(b.X, b.Y) {
+			bites = append(bites, b)
+		}
+	}
+	l.StaticBites = bites
+
+	var walls []engine.MovingWall
+	for _, mw := range l.MovingWalls {
+		if !at(mw.X, mw.Y) {
+			walls = append(walls, mw)
+		}
+	}
+	l.MovingWalls = walls
+}
+
+// Save writes the level to the editor's file.
+func (ed *Editor) Save() error {
+	if err := os.MkdirAll(filepath.Dir(ed.file), 0755); err != nil {
+		return err
+	}
+	if err := engine.WriteLevel(ed.Level(), ed.file); err != nil {
+		return err
+	}
+	ed.changed = false
+	logger.Infof("Saved level: %v", ed.file)
+	return nil
+}
+
+// MenuEditor lets the player pick a level file to edit or start a new one
+// and then runs the level editor.
+func (g *Game) MenuEditor() int {
+	// Make sure the default levels exist so they can be edited
+	if _, err := engine.LoadLevels(g.levelDir, g.mapWidth, g.mapHeight); err != nil {
+		logger.Errorf("Error loading levels: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(g.levelDir, "*.json"))
+	if err != nil {
+		logger.Errorf("Error listing levels: %v", err)
+	}
+
+	var levels []*engine.Level
+	var levelFiles, options []string
+	maxScore := -20
+	for _, file := range files {
+		l, err := engine.ReadLevel(file)
+		if err != nil {
+			logger.Errorf("Error reading level: %v", err)
+			continue
+		}
+		if l.Score > maxScore {
+			maxScore = l.Score
+		}
+		levels = append(levels, l)
+		levelFiles = append(levelFiles, file)
+		options = append(options, filepath.Base(file)+" - "+l.Name)
+	}
+	options = append(options, "New Level")
+
+	g.gview.Clear()
+	renderCenterStr(g.gview, g.viewWidth, g.viewHeight-4, g.DefStyle, "Edit Level:")
+	i := g.handleMenu(options)
+	if i == ItemExit {
+		return MenuMain
+	}
+
+	var ed *Editor
+	if i < len(levels) {
+		ed = NewEditor(levels[i], levelFiles[i], g.mapWidth, g.mapHeight)
+	} else {
+		// Pick the first free level file name
+		n := len(files) + 1
+		file := filepath.Join(g.levelDir, fmt.Sprintf("level%02d.json", n))
+		for _, err := os.Stat(file); err == nil; _, err = os.Stat(file) {
+			n++
+			file = filepath.Join(g.levelDir, fmt.Sprintf("level%02d.json", n))
+		}
+		l := &engine.Level{Name: fmt.Sprintf("Level %v", n), Score: maxScore + 20}
+		ed = NewEditor(l, file, g.mapWidth, g.mapHeight)
+	}
+	g.RunEditor(ed)
+	g.resetCamera()
+
+	return MenuMain
+}
+
+// RunEditor runs the level editor until the player leaves it.
+func (g *Game) RunEditor(ed *Editor) {
+	g.events = make(chan tcell.Event, 16)
+	done := make(chan bool)
+	go g.pollEvents(done)
+	defer func() {
+		// Wake the event poller so it stops before the menus read events again
+		close(done)
+		g.screen.PostEvent(tcell.NewEventInterrupt(nil))
+	}()
+
+	for {
+		renderEditor(g, ed)
+		ev := <-g.events
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			g.handleResize()
+		case *tcell.EventKey:
+			ed.message = ""
+			switch ev.Key() {
+			case tcell.KeyEscape:
+				return
+			case tcell.KeyExit:
+				g.Quit()
+			case tcell.KeyUp:
+				if ed.y > 0 {
+					ed.y--
+				}
+			case tcell.KeyDown:
+				if ed.y < len(ed.grid)-1 {
+					ed.y++
+				}
+			case tcell.KeyLeft:
+				if ed.x > 0 {
+					ed.x--
+				}
+			case tcell.KeyRight:
+				if ed.x < len(ed.grid[0])-1 {
+					ed.x++
+				}
+			case tcell.KeyTab:
+				ed.tool = (ed.tool + 1) % len(editorTools)
+			case tcell.KeyEnter:
+				ed.Place()
+			case tcell.KeyDelete, tcell.KeyBackspace, tcell.KeyBackspace2:
+				ed.Erase()
+			case tcell.KeyCtrlZ:
+				ed.Undo()
+			case tcell.KeyCtrlY:
+				ed.Redo()
+			case tcell.KeyCtrlS:
+				if err := ed.Save(); err != nil {
+					logger.Errorf("Error saving level: %v", err)
+					ed.message = "Error saving level: " + err.Error()
+				} else {
+					ed.message = "Saved " + ed.file
+				}
+			}
+			switch r := ev.Rune(); {
+			case r == ' ':
+				ed.Place()
+			case r == 'x':
+				ed.Erase()
+			case r == 'r':
+				ed.dir = (ed.dir + 1) % len(editorDirs)
+			case r == '+' || r == '=':
+				ed.length++
+			case r == '-':
+				if ed.length > 1 {
+					ed.length--
+				}
+			case r >= '1' && r < '1'+rune(len(editorTools)):
+				ed.tool = int(r - '1')
+			case r == 't':
+				g.testLevel(ed)
+			}
+		}
+	}
+}
+
+// testLevel plays the level being edited with a single test player until
+// they die or escape is pressed.
+func (g *Game) testLevel(ed *Editor) {
+	l := *ed.Level()
+	l.Score = 0
+	hMove, vMove := g.settings.moveTimes()
+	config := engine.Config{
+		Width:          len(ed.grid[0]),
+		Height:         len(ed.grid),
+		Mode:           Advanced,
+		NumBits:        g.settings.NumBits,
+		Players:        []engine.PlayerConfig{{Name: "Test", FGColor: "white", BGColor: "black", Char: PlayerRune}},
+		Rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		Levels:         []*engine.Level{&l},
+		HorizontalMove: hMove,
+		VerticalMove:   vMove,
+		Wrap:           g.settings.Wrap,
+		OpenBorder:     g.settings.OpenBorder,
+	}
+
+	numPlayers := g.numPlayers
+	g.numPlayers = 1
+	g.engine = engine.NewEngine(config)
+	g.controls = playerControls(g.settings.Keys.Players[0]) + " - esc = back to editor"
+	defer func() {
+		g.numPlayers = numPlayers
+		g.controls = g.gameControls()
+	}()
+
+	ticker := time.NewTicker(engine.TickDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-g.events:
+			switch ev := ev.(type) {
+			case *tcell.EventResize:
+				g.handleResize()
+			case *tcell.EventKey:
+				if ev.Key() == tcell.KeyEscape {
+					return
+				}
+				handlePlayerInput(g, ev)
+			case *padEvent:
+				handlePadInput(g, ev)
+			}
+		case <-ticker.C:
+			g.engine.Step(g.inputs)
+			g.inputs = nil
+			renderAll(g, g.DefStyle, g.engine.Snapshot())
+			if g.engine.IsOver() {
+				return
+			}
+		}
+	}
+}
+
+// Render the level editor
+func renderEditor(g *Game, ed *Editor) {
+	// Keep the cursor in the middle of the view on large maps
+	g.resetCamera()
+	g.gview.Center(ed.x, ed.y)
+	g.gview.Clear()
+	l := ed.level
+
+	for y, row := range ed.grid {
+		for x, c := range row {
+			switch c {
+			case gamemap.TileWall:
+				renderRune(g.gview, x, y, g.DefStyle, engine.WallRune)
+			case gamemap.TileSpawn, gamemap.TileBit:
+				renderRune(g.gview, x, y, g.SelStyle, c)
+			case gamemap.TileItem:
+				renderRune(g.gview, x, y, g.DefStyle, engine.ItemRune)
+			default:
+				if i := strings.IndexRune(gamemap.TilePortals, c); i >= 0 {
+					renderRune(g.gview, x, y, g.PortalStyles[i%len(g.PortalStyles)], engine.PortalRune)
+				}
+			}
+		}
+	}
+	for _, r := range l.Walls {
+		x, y := ed.cell(r.X, r.Y)
+		for i := x; i < x+r.W; i++ {
+			for j := y; j < y+r.H; j++ {
+				renderRune(g.gview, i, j, g.DefStyle, engine.WallRune)
+			}
+		}
+	}
+	for _, b := range l.BitLines {
+		x, y := ed.cell(b.X, b.Y)
+		for i := 0; i < b.Length; i++ {
+			if b.Direction == "right" {
+				renderRune(g.gview, x+i*2, y, g.BitStyle, engine.BitRune)
+			} else {
+				renderRune(g.gview, x, y+i, g.BitStyle, engine.BitRune)
+			}
+		}
+	}
+	for _, b := range l.StaticBites {
+		x, y := ed.cell(b.X, b.Y)
+		for d, name := range editorDirs {
+			if name == b.Direction {
+				renderRune(g.gview, x, y, g.BiteExplodedStyle, engine.BiteRunes[d])
+			}
+		}
+	}
+	for _, i := range l.Items {
+		x, y := ed.cell(i.X, i.Y)
+		renderRune(g.gview, x, y, g.DefStyle, engine.ItemRune)
+	}
+	for _, w := range l.MovingWalls {
+		x, y := ed.cell(w.X, w.Y)
+		for d, name := range editorDirs {
+			if name == w.Direction {
+				renderRune(g.gview, x, y, g.DefStyle, engine.BiteRunes[d])
+			}
+		}
+	}
+
+	// Draw the cursor and the editor status along the top of the view
+	renderRune(g.gview, ed.x, ed.y, g.SelStyle, '+')
+	changed := ""
+	if ed.changed {
+		changed = "*"
+	}
+	status := fmt.Sprintf(" %v%v - %v - %v - length %v - %v,%v ", filepath.Base(ed.file), changed, editorTools[ed.tool], editorDirs[ed.dir], ed.length, ed.x, ed.y)
+	renderStr(g.hview, 2, 0, g.SelStyle, status)
+
+	help := editorControls
+	if ed.message != "" {
+		help = ed.message
+	}
+	g.sbar.SetCenter(help, g.DefStyle)
+	g.sbar.Draw()
+	g.screen.Show()
+}
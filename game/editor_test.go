@@ -0,0 +1,78 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stjiub/gosnake/engine"
+	"github.com/stjiub/gosnake/gamemap"
+)
+
+// place uses a tool of the editor on each cell.
+func place(ed *Editor, tool int, cells ...[2]int) {
+	ed.tool = tool
+	for _, c := range cells {
+		ed.x, ed.y = c[0], c[1]
+		ed.Place()
+	}
+}
+
+func TestEditorLevelKeepsPlacesInLayout(t *testing.T) {
+	ed := NewEditor(&engine.Level{Name: "maze"}, "", MinMapWidth, MinMapHeight)
+
+	// A pocket for a bite and a ledge for an item
+	place(ed, ToolWall, [2]int{24, 11}, [2]int{26, 11}, [2]int{25, 10}, [2]int{30, 14}, [2]int{31, 14})
+	place(ed, ToolBite, [2]int{25, 11})
+	place(ed, ToolItem, [2]int{30, 15})
+	if ed.message != "" {
+		t.Fatal(ed.message)
+	}
+	l := ed.Level()
+	if !l.FromLayout {
+		t.Fatal("the level is not placed from its layout")
+	}
+	l, err := engine.DecodeLevel(engine.EncodeLevel(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range [][2]int{{MinMapWidth, MinMapHeight}, {DefaultMapWidth, DefaultMapHeight}, {151, 50}} {
+		if err := l.Validate(size[0], size[1]); err != nil {
+			t.Fatalf("%v: %v", size, err)
+		}
+		e := engine.NewEngine(engine.Config{
+			Width:   size[0],
+			Height:  size[1],
+			Players: []engine.PlayerConfig{{Name: "a"}},
+			Rand:    rand.New(rand.NewSource(1)),
+			Levels:  []*engine.Level{l},
+		})
+		s := e.Snapshot()
+		blocked := func(x, y int) bool {
+			return s.Map.Objects[x][y].IsBlocked()
+		}
+		if len(s.Bites) != 1 || len(s.Items) != 1 {
+			t.Fatalf("%v: got %v bites and %v items", size, len(s.Bites), len(s.Items))
+		}
+		if x, y := s.Bites[0].GetCurPos(); blocked(x, y) || !blocked(x-1, y) || !blocked(x+1, y) || !blocked(x, y-1) {
+			t.Errorf("%v: the bite at %v,%v is not in its pocket", size, x, y)
+		}
+		if x, y := s.Items[0].GetCurPos(); blocked(x, y) || !blocked(x, y-1) || !blocked(x+1, y-1) {
+			t.Errorf("%v: the item at %v,%v is not under its ledge", size, x, y)
+		}
+	}
+}
+
+func TestEditorUndo(t *testing.T) {
+	ed := NewEditor(&engine.Level{Name: "undo"}, "", MinMapWidth, MinMapHeight)
+	place(ed, ToolWall, [2]int{10, 10})
+	place(ed, ToolBite, [2]int{12, 10})
+	ed.Undo()
+	if len(ed.level.StaticBites) != 0 || ed.grid[10][10] != gamemap.TileWall {
+		t.Fatal("undo did not go back to the wall")
+	}
+	ed.Redo()
+	if x, y := ed.cell(ed.level.StaticBites[0].X, ed.level.StaticBites[0].Y); x != 12 || y != 10 {
+		t.Fatalf("redo put the bite at %v,%v", x, y)
+	}
+}
@@ -1,6 +1,8 @@
 package game
 
 import (
+	"fmt"
+	"math/rand"
 	"os"
 	"strconv"
 	"time"
@@ -10,111 +12,157 @@ import (
 	"github.com/gdamore/tcell"
 	"github.com/gdamore/tcell/encoding"
 	"github.com/gdamore/tcell/views"
-	"github.com/stjiub/gosnake/entity"
-	"github.com/stjiub/gosnake/gamemap"
+	"github.com/stjiub/gosnake/engine"
 	"github.com/stjiub/gosnake/style"
 )
 
 const (
 	// Map values
-	MapWidth  = 100
-	MapHeight = 35
-	MapStartX = 0
-	MapStartY = 0
+	DefaultMapWidth  = 100
+	DefaultMapHeight = 35
+	MinMapWidth      = 60
+	MinMapHeight     = 24
 
-	// Control bar values
-	SViewWidth  = MapWidth
+	// Control bar values. The bar sits one row below the map.
 	SViewHeight = 1
-	SViewStartX = 0
-	SViewStartY = MapHeight + 1
 
 	// High Score count
 	MaxHighScores = 5
 
+	// Points a snake needs to speed up in Basic
+	BasicSpeedUp = 50
+
 	// Game runes
-	PlayerRune      rune = '█'
-	BitRune         rune = '■'
-	WallRune        rune = '▒'
-	FloorRune       rune = ' '
-	BiteUpRune      rune = '▲'
-	BiteDownRune    rune = '▼'
-	BiteLeftRune    rune = '◄'
-	BiteRightRune   rune = '►'
-	BiteAllRune     rune = '◆'
-	BiteExplodeRune rune = '░'
+	PlayerRune rune = '█'
 )
 
 var (
-	// Current game map
-	m *gamemap.GameMap
-
-	// Number of random bits that should be present on map at a time
-	numBits int = 5
-
-	// Text to be displayed at bottom for controls
-	controls        string = "w/s/a/d = up/down/left/right - esc = quit - f1 = restart - f12 = pause"
-	mainOptions            = []string{"Play", "High Scores", "Settings"}
-	playerOptions          = []string{"1 Player", "2 Player"}
-	gameModeOptions        = []string{"Basic", "Advanced", "Battle"}
-	PlayerRunes            = []rune{'█', '■', '◆', '࿖', 'ᚙ', '▚', 'ↀ', 'ↈ', 'ʘ', '֍', '߷', '⁂', 'O', 'o', '=', '#', '$', '+', '-', '!', '('}
-	PlayerColors           = []string{"white", "black", "silver", "green", "lime", "blue", "navy", "aqua", "teal", "red", "purple", "fuschia"}
-	BiteRunes              = []rune{BiteUpRune, BiteDownRune, BiteLeftRune, BiteRightRune, BiteAllRune, BiteExplodeRune}
+	mainOptions     = []string{"Play", "Campaign", "Daily Challenge", "Puzzle", "High Scores", "Level Editor", "Settings"}
+	playerOptions   = []string{"1 Player", "2 Player", "3 Player", "4 Player"}
+	gameModeOptions = []string{"Basic", "Advanced", "Battle", "Time Attack", "Survival", "Co-op", "Light Cycle"}
+
+	// Game mode of each gameModeOptions entry and the name of every mode
+	// with a high score table
+	gameModes    = []int{Basic, Advanced, Battle, TimeAttack, Survival, Coop, LightCycle}
+	modeNames    = map[int]string{Basic: "Basic", Advanced: "Advanced", Battle: "Battle", TimeAttack: "Time Attack", Survival: "Survival", Coop: "Co-op", Daily: "Daily Challenge", LightCycle: "Light Cycle"}
+	scoreModes   = []int{Basic, Advanced, Battle, TimeAttack, Survival, Coop, Daily}
+	minPlayers   = map[int]int{Battle: 2, Coop: 2, LightCycle: 2}
+	PlayerRunes  = []rune{'█', '■', '◆', '࿖', 'ᚙ', '▚', 'ↀ', 'ↈ', 'ʘ', '֍', '߷', '⁂', 'O', 'o', '=', '#', '$', '+', '-', '!', '('}
+	PlayerColors = []string{"white", "black", "silver", "green", "lime", "blue", "navy", "aqua", "teal", "red", "purple", "fuschia"}
 )
 
 // Game is the main game struct and is used to store and compute general game logic.
 type Game struct {
 
 	// Screen and views
-	screen tcell.Screen    // Main Screen
-	gview  *views.ViewPort // Game view port
-	sview  *views.ViewPort // Controls view port
-	sbar   *views.TextBar  // Controls text bar
-
-	// Game structs
-	players  []*entity.Player // All players in game
-	entities []*entity.Entity // All entities currently in game
-	bites    []*entity.Bit    // All bites currently  in game (triangles)
-	bits     []*entity.Bit    // All bits currently in game (square dots)
-	items    []*entity.Item
-	gameMap  *gamemap.GameMap // Game map
-	biteMap  *gamemap.GameMap // Bite map
+	screen tcell.Screen      // Main Screen
+	gview  *views.ViewPort   // Game view port
+	hview  *views.ViewPort   // Fixed view port over the game view for text
+	pviews []*views.ViewPort // One view port per player for the split camera
+	sview  *views.ViewPort   // Controls view port
+	sbar   *views.TextBar    // Controls text bar
+
+	// Game rules
+	engine  *engine.Engine   // Runs the current match
+	config  engine.Config    // Config the current match was started with
+	inputs  []engine.Input   // Inputs waiting for the next tick
+	history [][]engine.Input // Inputs of each puzzle move so far
 
 	// Score and profile tracking
-	scores1     []*Score   // 1 player scores
-	scores2     []*Score   // 2 player scores
-	scoreFile   string     // File that stores the scores
-	profiles    []*Profile // Player profiles
-	curProfiles []*Profile // Currently selected profiles
-	proFile     string     // File that stores the profiles
+	scores      map[int][]*Score // High score table of each mode
+	scoreFile   string           // File that stores the scores
+	profiles    []*Profile       // Player profiles
+	curProfiles []*Profile       // Currently selected profiles
+	proFile     string           // File that stores the profiles
+	levelDir    string           // Directory that stores the level files
+	campaignDir string           // Directory that stores the campaign stages
+	stages      []*engine.Level  // Campaign stages
+	stage       int              // Campaign stage being played
+	date        string           // Date of the daily challenge being played
+	puzzleDir   string           // Directory that stores the puzzle packs
+	packs       []*engine.PuzzlePack
+	pack        int       // Puzzle pack being played
+	puzzle      int       // Puzzle being played
+	settings    *Settings // Options picked in the settings menu
+	pads        *Pads     // Connected controllers
+	padPlayer   int       // Player a controller is assigned to when used in the profile menu
+	configFile  string    // File that stores the settings
+
+	// Replay recording
+	replay    *Replay // Inputs recorded during the current match
+	replayDir string  // Directory that stores the replays
+
+	// Map size and screen layout
+	mapWidth   int  // Map width
+	mapHeight  int  // Map height
+	autoSize   bool // Size the map to fit the terminal
+	viewX      int  // Screen column of the game view's left edge
+	viewY      int  // Screen row of the game view's top edge
+	viewWidth  int  // Width of the game view, at most the map width
+	viewHeight int  // Height of the game view, at most the map height
+	camera     int  // How players share the screen
 
 	// Misc variables
-	state      int       // Game state
-	mode       int       // Game mode
-	level      int       // Current game level
-	numPlayers int       // Chosen number of players for game
-	fps        int       // Game FPS
-	frames     int       // Used to track game FPS
-	bitQuit    chan bool // Used to close handlebits goroutine
+	state      int              // Game state
+	mode       int              // Game mode
+	numPlayers int              // Chosen number of players for game
+	fps        int              // Game FPS
+	frames     int              // Used to track game FPS
+	seed       int64            // Seed for the match's random number generator
+	controls   string           // Text displayed in the controls bar
+	events     chan tcell.Event // Screen events waiting to be handled
 
 	style.Style
 }
 
-func NewGame(numPlayers int, curProfiles []*Profile, scoreFile, proFile string) *Game {
+func NewGame(numPlayers int, curProfiles []*Profile, settings *Settings, configFile, scoreFile, proFile, levelDir, campaignDir, puzzleDir, replayDir string, seed int64) *Game {
+	if settings == nil {
+		settings = DefaultSettings()
+	}
 	g := Game{
 		numPlayers:  numPlayers,
 		curProfiles: curProfiles,
+		settings:    settings,
+		configFile:  configFile,
 		scoreFile:   scoreFile,
 		proFile:     proFile,
+		levelDir:    levelDir,
+		campaignDir: campaignDir,
+		puzzleDir:   puzzleDir,
+		replayDir:   replayDir,
+		seed:        seed,
+		mapWidth:    DefaultMapWidth,
+		mapHeight:   DefaultMapHeight,
+		padPlayer:   -1,
 	}
+	g.applySettings()
 
 	return &g
 }
 
+// SetMapSize sets the map size from a "WIDTHxHEIGHT" string. A size of
+// "auto" sizes the map to fill the terminal.
+func (g *Game) SetMapSize(size string) error {
+	if size == "auto" {
+		g.autoSize = true
+		return nil
+	}
+	var w, h int
+	if _, err := fmt.Sscanf(size, "%dx%d", &w, &h); err != nil {
+		return fmt.Errorf("map size must be WIDTHxHEIGHT or auto, got %q", size)
+	}
+	if w < MinMapWidth || h < MinMapHeight {
+		return fmt.Errorf("map size must be at least %vx%v", MinMapWidth, MinMapHeight)
+	}
+	g.mapWidth, g.mapHeight, g.autoSize = w, h, false
+	return nil
+}
+
 // InitScreen initializes the tcell screen and sets views/bars and styles.
 func (g *Game) InitScreen() error {
 
 	// Set style
-	g.SetDefaultStyle()
+	g.SetTheme(g.settings.Theme)
 
 	encoding.Register()
 
@@ -128,25 +176,103 @@ func (g *Game) InitScreen() error {
 	} else {
 		screen.SetStyle(g.DefStyle)
 		g.screen = screen
+		if g.pads != nil {
+			g.pads.setScreen(screen)
+		}
 		logger.Info("Intialized screen...")
 	}
 
-	// Display cursor at bottom of screen. Seems to be an issue with
-	// Windows Terminal and hiding the cursor completely
-	g.screen.ShowCursor(0, MapHeight+3)
-
-	// Create the main game viewport
-	g.gview = views.NewViewPort(g.screen, MapStartX, MapStartY, MapWidth, MapHeight)
+	// Create the main game viewport and the text viewport on top of it
+	g.gview = views.NewViewPort(g.screen, 0, 0, g.mapWidth, g.mapHeight)
+	g.hview = views.NewViewPort(g.screen, 0, 0, g.mapWidth, g.mapHeight)
 
 	// Create the secondary view port and text bars for the controls display
-	g.sview = views.NewViewPort(g.screen, SViewStartX, SViewStartY, SViewWidth, SViewHeight)
+	g.sview = views.NewViewPort(g.screen, 0, g.mapHeight+1, g.mapWidth, SViewHeight)
 	g.sbar = views.NewTextBar()
 	g.sbar.SetView(g.sview)
 	g.sbar.SetStyle(g.DefStyle)
 
+	// Size the map and place the views for the current terminal size
+	g.layout(true)
+
 	return nil
 }
 
+// layout places the views in the middle of the terminal. Maps larger than
+// the terminal are scrolled by the camera. If resizeMap is set and the map
+// size is auto the map is first sized to fill the terminal.
+func (g *Game) layout(resizeMap bool) {
+	sw, sh := g.screen.Size()
+	if resizeMap && g.autoSize {
+		g.mapWidth, g.mapHeight = sw, sh-SViewHeight-1
+		if g.mapWidth < MinMapWidth {
+			g.mapWidth = MinMapWidth
+		}
+		if g.mapHeight < MinMapHeight {
+			g.mapHeight = MinMapHeight
+		}
+	}
+
+	// The game view shows as much of the map as fits on screen
+	g.viewWidth, g.viewHeight = g.mapWidth, g.mapHeight
+	if sw < g.viewWidth {
+		g.viewWidth = sw
+	}
+	if h := sh - SViewHeight - 1; h < g.viewHeight {
+		g.viewHeight = h
+	}
+	if g.viewWidth < 1 {
+		g.viewWidth = 1
+	}
+	if g.viewHeight < 1 {
+		g.viewHeight = 1
+	}
+
+	g.viewX, g.viewY = 0, 0
+	if sw > g.viewWidth {
+		g.viewX = (sw - g.viewWidth) / 2
+	}
+	if h := g.viewHeight + SViewHeight + 1; sh > h {
+		g.viewY = (sh - h) / 2
+	}
+	g.gview.Resize(g.viewX, g.viewY, g.viewWidth, g.viewHeight)
+	g.hview.Resize(g.viewX, g.viewY, g.viewWidth, g.viewHeight)
+	g.hview.SetContentSize(g.viewWidth, g.viewHeight, true)
+	g.resetCamera()
+	g.layoutSplit()
+	g.sview.Resize(g.viewX, g.viewY+g.viewHeight+1, g.viewWidth, SViewHeight)
+	g.sbar.Resize()
+
+	// Display cursor below the map. Seems to be an issue with
+	// Windows Terminal and hiding the cursor completely
+	g.screen.ShowCursor(g.viewX, g.viewY+g.viewHeight)
+}
+
+// fits reports whether enough of the map is on screen to play. Larger maps
+// scroll but the view has to be at least the size of the smallest map.
+func (g *Game) fits() bool {
+	sw, sh := g.screen.Size()
+	w, h := g.mapWidth, g.mapHeight
+	if w > MinMapWidth {
+		w = MinMapWidth
+	}
+	if h > MinMapHeight {
+		h = MinMapHeight
+	}
+	return sw >= w && sh >= h+SViewHeight+1
+}
+
+// handleResize lays the views out again after the terminal is resized. A
+// running match is paused if the map no longer fits.
+func (g *Game) handleResize() {
+	g.layout(false)
+	g.screen.Clear()
+	g.screen.Sync()
+	if g.state == Play && !g.fits() {
+		g.state = Pause
+	}
+}
+
 // MainMenu displays and handles input for the Main Menu.
 func (g *Game) MainMenu() error {
 
@@ -170,15 +296,42 @@ func (g *Game) MainMenu() error {
 			cMenu = g.MenuPlayer()
 			logger.Infof("%v", cMenu)
 		}
+		// Display the game mode menu
+		if cMenu == MenuMode {
+			cMenu = g.MenuMode()
+		}
 		// Display the player profile menu and let players pick their
 		// profile or create a new one.
 		if cMenu == MenuProfile {
 			cMenu = g.MenuProfile(cMenu)
+
+			// Campaign players pick a stage once they have a profile
+			if g.state == Play && g.mode == Campaign {
+				cMenu = g.MenuStage()
+			}
+
+			// Daily challenge players get shown the day's rules
+			if g.state == Play && g.mode == Daily {
+				cMenu = g.MenuDaily()
+			}
+
+			// Puzzle players pick a puzzle
+			if g.state == Play && g.mode == Puzzle {
+				cMenu = g.MenuPuzzle()
+			}
 		}
 		// Display the high score screen
 		if cMenu == MenuScore {
 			cMenu = g.MenuScore(cMenu)
 		}
+		// Pick a level and open it in the level editor
+		if cMenu == MenuEditor {
+			cMenu = g.MenuEditor()
+		}
+		// Change and save the settings
+		if cMenu == MenuSettings {
+			cMenu = g.MenuSettings()
+		}
 	}
 	return nil
 }
@@ -186,8 +339,8 @@ func (g *Game) MainMenu() error {
 func (g *Game) MenuMain() int {
 	var cMenu int
 	g.gview.Clear()
-	renderSnakeLogo(g, MapWidth/2, MapHeight/2)
-	renderGoLogo(g, MapWidth/2, MapHeight/2)
+	renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+	renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
 	i := g.handleMenu(mainOptions)
 	switch i {
 	case ItemExit:
@@ -196,31 +349,42 @@ func (g *Game) MenuMain() int {
 	case 0:
 		return MenuPlayer
 	case 1:
+		// The campaign is played alone
+		g.numPlayers = 1
+		g.mode = Campaign
+		return MenuProfile
+	case 2:
+		// So is the daily challenge
+		g.numPlayers = 1
+		g.mode = Daily
+		return MenuProfile
+	case 3:
+		// And puzzles
+		g.numPlayers = 1
+		g.mode = Puzzle
+		return MenuProfile
+	case 4:
 		return MenuScore
+	case 5:
+		return MenuEditor
+	case 6:
+		return MenuSettings
 	}
 	return cMenu
 }
 
 func (g *Game) MenuPlayer() int {
-	var cMenu int
 	g.gview.Clear()
-	renderSnakeLogo(g, MapWidth/2, MapHeight/2)
-	renderGoLogo(g, MapWidth/2, MapHeight/2)
+	renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+	renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
 	i := g.handleMenu(playerOptions)
 	switch i {
 	case ItemExit:
 		return MenuMain
-	case 0:
-		g.numPlayers = 1
-		g.mode = Player1
-		return MenuProfile
-	case 1:
-		g.numPlayers = 2
-		g.mode = Player2
-		return MenuProfile
+	default:
+		g.numPlayers = i + 1
+		return MenuMode
 	}
-
-	return cMenu
 }
 
 func (g *Game) MenuProfile(cMenu int) int {
@@ -246,8 +410,8 @@ func (g *Game) MenuProfile(cMenu int) int {
 
 			// Draw the Select Profile text
 			g.screen.Clear()
-			renderSnakeLogo(g, MapWidth/2, MapHeight/2)
-			renderGoLogo(g, MapWidth/2, MapHeight/2)
+			renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+			renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
 			if cMenu == MenuProfile {
 				// If 1 Player mode don't show a number, if 2 player then
 				// show which player number during profile select
@@ -256,18 +420,25 @@ func (g *Game) MenuProfile(cMenu int) int {
 				} else {
 					pNum = ""
 				}
-				renderCenterStr(g.gview, MapWidth, MapHeight-4, g.DefStyle, ("  Select Profile " + pNum + ":"))
+				renderCenterStr(g.gview, g.viewWidth, g.viewHeight-4, g.DefStyle, ("  Select Profile " + pNum + ":"))
 			} else if cMenu == MenuEdit {
-				renderCenterStr(g.gview, MapWidth, MapHeight-4, g.DefStyle, ("  Edit Profile:"))
+				renderCenterStr(g.gview, g.viewWidth, g.viewHeight-4, g.DefStyle, ("  Edit Profile:"))
 			} else {
-				renderCenterStr(g.gview, MapWidth, MapHeight-4, g.DefStyle, ("  Remove Profile:"))
+				renderCenterStr(g.gview, g.viewWidth, g.viewHeight-4, g.DefStyle, ("  Remove Profile:"))
 			}
 			g.screen.Show()
 
 			// Draw and handle the player select menu. The list of menu items
-			// is generated using the list of profiles read from file.
+			// is generated using the list of profiles read from file. A
+			// controller used to pick the profile is given to the player.
 			if len(profileList) > 0 {
+				if cMenu == MenuProfile && g.pads.count() > 0 {
+					g.padPlayer = a
+					g.sbar.SetCenter(padControls, g.DefStyle)
+					g.sbar.Draw()
+				}
 				i := g.handleMenu(profileList)
+				g.padPlayer = -1
 
 				// Drop back to MenuMain if Escape is pressed
 				if i == ItemExit {
@@ -308,127 +479,302 @@ func (g *Game) MenuProfile(cMenu int) int {
 }
 
 func (g *Game) MenuScore(cMenu int) int {
+	page := 0
+	date := today()
 	for cMenu == MenuScore {
 		g.screen.Clear()
-		renderHighScoreScreen(g, g.DefStyle, MaxHighScores)
+		renderHighScoreScreen(g, g.DefStyle, scoreModes[page], date)
 
-		// Wait for Escape key to be pressed to return to Main Menu
+		// Left and right change the mode and Escape returns to Main Menu
 		ev := g.screen.PollEvent()
 		switch ev := ev.(type) {
 		case *tcell.EventKey:
-			if ev.Key() == tcell.KeyEscape {
+			switch ev.Key() {
+			case tcell.KeyEscape:
 				return MenuMain
+			case tcell.KeyLeft:
+				page = step(page, -1, len(scoreModes))
+			case tcell.KeyRight:
+				page = step(page, 1, len(scoreModes))
+			case tcell.KeyUp:
+				date = addDays(date, -1)
+			case tcell.KeyDown:
+				if date < today() {
+					date = addDays(date, 1)
+				}
 			}
 		}
 	}
 	return cMenu
 }
 
-// InitMap generates new maps for the game.
-func (g *Game) InitMap() error {
-
-	// Initialize game states
-	g.level = 1
-
-	// Create a game map
-	m = &gamemap.GameMap{
-		Width:  MapWidth,
-		Height: MapHeight,
-		X:      MapStartX,
-		Y:      MapStartY,
+// InitEngine creates a new match for the selected players and starts
+// recording it.
+func (g *Game) InitEngine() error {
+	config := g.engineConfig()
+
+	// Load the level progression, falling back to the built in levels.
+	// Basic, Time Attack, Survival and Light Cycle always start on the same
+	// open level.
+	// A campaign is played one stage at a time. The daily challenge
+	// always uses the built in levels.
+	rules := g.rules()
+	if rules == Basic || rules == TimeAttack || rules == Survival || rules == LightCycle {
+		config.Levels = engine.BasicLevels()
+	} else if g.mode == Daily {
+		config.Levels = engine.DefaultLevels()
+	} else if g.mode == Campaign {
+		if g.stages == nil {
+			g.loadStages()
+		}
+		if g.stage >= len(g.stages) {
+			g.stage = 0
+		}
+		config.Levels = []*engine.Level{g.stages[g.stage]}
+	} else if g.mode == Puzzle {
+		config.Levels = []*engine.Level{g.curPuzzle().Level()}
+	} else {
+		levels, err := engine.LoadLevels(g.levelDir, config.Width, config.Height)
+		if err != nil {
+			logger.Errorf("Error loading levels, using default levels: %v", err)
+			levels = engine.DefaultLevels()
+		}
+		config.Levels = levels
 	}
-	g.gameMap = m
-	m.InitMap()
-	m.InitMapBoundary(WallRune, FloorRune, g.DefStyle)
-	InitLevel1(g)
-	logger.Info("Created game map and set to level 1.")
 
-	biteMap := &gamemap.GameMap{
-		Width:  m.Width,
-		Height: m.Height,
+	// The daily challenge is always played on the same map size and is
+	// used up as soon as it starts
+	if g.mode == Daily {
+		g.mapWidth, g.mapHeight, g.autoSize = config.Width, config.Height, false
+		g.recordDaily(g.curProfiles[0].Name, 0)
 	}
-	biteMap.InitMap()
-	biteMap.InitMapBoundary(WallRune, FloorRune, g.DefStyle)
-	g.biteMap = biteMap
+
+	g.engine = engine.NewEngine(config)
+	g.config = config
+	g.history = nil
+	g.replay = NewReplay(g.seed, g.mode, config, g.curProfiles)
+	logger.Infof("Initialized game with %v players and seed %v.", g.numPlayers, g.seed)
 
 	return nil
 }
 
-// InitPlayers creates player objects for the game.
-func (g *Game) InitPlayers() error {
-	// Set player starting x value to middle of map
-	x := MapWidth / 2
+// engineConfig creates the engine config for the selected players.
+func (g *Game) engineConfig() engine.Config {
+	var players []engine.PlayerConfig
 
-	// Create a player for selected number of players
+	// Get player vars from loaded profiles
 	for i := 0; i < g.numPlayers; i++ {
-		y := (MapHeight / 2) + (i * 2)
-
-		// Get player vars from loaded profile
-		pName := g.curProfiles[i].Name
-		pStyle := g.curProfiles[i].GetStyle()
-		pChar := g.curProfiles[i].Char
+		p := g.curProfiles[i]
+		players = append(players, engine.PlayerConfig{
+			Name:    p.Name,
+			FGColor: p.FGColor,
+			BGColor: p.BGColor,
+			Char:    p.Char,
+		})
+	}
 
-		// Create player and
-		p := entity.NewPlayer(x, y, 0, (entity.DirLeft - i), pChar, pName, pStyle)
-		g.players = append(g.players, p)
+	hMove, vMove := g.settings.moveTimes()
+	config := engine.Config{
+		Width:          g.mapWidth,
+		Height:         g.mapHeight,
+		Mode:           g.mode,
+		NumBits:        g.settings.NumBits,
+		Players:        players,
+		Rand:           rand.New(rand.NewSource(g.seed)),
+		HorizontalMove: hMove,
+		VerticalMove:   vMove,
+		Wrap:           g.settings.Wrap,
+		OpenBorder:     g.settings.OpenBorder,
+	}
+	switch g.rules() {
+	case Basic:
+		config.SpeedUp = BasicSpeedUp
+	case Battle:
+		battleConfig(&config)
+	case TimeAttack:
+		timeAttackConfig(&config)
+	case Survival:
+		survivalConfig(&config)
+	case Coop:
+		coopConfig(&config, g.settings)
+	case Campaign:
+		campaignConfig(&config)
+	case LightCycle:
+		lightCycleConfig(&config)
+	case Puzzle:
+		if g.packs == nil {
+			g.loadPuzzles()
+		}
+		if g.pack >= len(g.packs) || g.puzzle >= len(g.packs[g.pack].Puzzles) {
+			g.pack, g.puzzle = 0, 0
+		}
+		puzzleConfig(&config, g.curPuzzle())
 	}
-	g.players[0].SetScore(0)
-	for i := 0; i < numBits; i++ {
-		b := entity.NewRandomBit(m, 10, BitRune, g.BitStyle)
-		g.bits = append(g.bits, b)
+	if g.mode == Daily {
+		dailyConfig(&config)
 	}
-	logger.Info("Initialized game with ", strconv.Itoa(g.numPlayers), " players.")
-
-	return nil
+	return config
 }
 
-// Run is the main game loop.
+// Run is the main game loop. Input is collected from the screen as it
+// arrives and the game is advanced one Step per tick.
 func (g *Game) Run() error {
-
-	// Run a goroutine for each player to handle their own loop
-	// separately from each other and the main game loop
-	for _, p := range g.players {
-		p.InitChans()
-		go g.handlePlayer(p)
+	g.events = make(chan tcell.Event, 16)
+	done := make(chan bool)
+	defer close(done)
+	go g.pollEvents(done)
+
+	ticker := time.NewTicker(engine.TickDuration)
+	defer ticker.Stop()
+
+	// Lay the views out for the number of players and start paused if the
+	// map does not fit in the terminal
+	g.layout(false)
+	if !g.fits() {
+		g.state = Pause
 	}
 
 	// The gameplay loop
 	for g.state == Play || g.state == Pause {
+		select {
+		case ev := <-g.events:
+			handleInput(g, ev)
+		case <-ticker.C:
+			if g.state == Pause {
+				g.handlePause()
+				continue
+			}
+			g.replay.Record(g.engine.GetTick()+1, g.inputs)
+			moves := g.engine.GetMoves()
+			events := g.engine.Step(g.inputs)
+			if g.engine.GetMoves() > moves {
+				g.history = append(g.history, g.inputs)
+			}
+			g.inputs = nil
 
-		// Handle entities and objects on level
-		g.handleLevel(m)
+			// Render the game
+			renderAll(g, g.DefStyle, g.engine.Snapshot())
 
-		// Run goroutine for player's input
-		go handleInput(g)
+			// Keep track of FPS
+			g.getFPS()
+			g.frames++
 
-		// Handle game if pause button is pressed
-		g.handlePause()
+			g.handleEvents(events)
+		}
+	}
 
-		for i := range g.players {
-			select {
-			case bitPos := <-g.players[i].BitChan:
-				g.bits = removeBit(g.bits, bitPos)
-			case bitePos := <-g.players[i].BiteChan:
-				g.bites = removeBit(g.bites, bitePos)
-			default:
-				continue
+	// Save the recording of the match
+	if g.replay.Ticks > 0 {
+		WriteReplay(g.replay, g.replayDir)
+	}
+
+	return nil
+}
+
+// handleEvents reacts to the events that happened during a tick.
+func (g *Game) handleEvents(events []engine.Event) {
+	for _, ev := range events {
+		switch ev.Type {
+		case engine.EventDeath:
+			g.bell()
+			if rules := g.rules(); rules != Battle && rules != TimeAttack && rules != Coop && rules != Campaign && rules != LightCycle && rules != Puzzle {
+				g.recordScore(ev.Name, ev.Score)
+			}
+		case engine.EventRound:
+			// The winner of a battle round gets their score recorded and
+			// every player gets theirs when the time attack clock runs out
+			g.bell()
+			if g.mode == Battle && ev.Player >= 0 {
+				g.recordScore(ev.Name, ev.Score)
+			}
+			if g.rules() == TimeAttack {
+				for _, p := range g.engine.Snapshot().Players {
+					g.recordScore(p.GetName(), p.GetScore())
+				}
+			}
+
+			// A cleared campaign stage earns a star for each life left
+			if g.mode == Campaign && ev.Player >= 0 {
+				g.clearStage(g.engine.Snapshot().Stats[0].Lives)
+			}
+			if g.mode == Puzzle && ev.Player >= 0 {
+				g.solvePuzzle(g.engine.GetMoves())
+			}
+		case engine.EventLevel, engine.EventGoal:
+			g.bell()
+		case engine.EventOver:
+			// A co-op team's score is recorded under both names
+			if g.mode == Coop {
+				g.recordScore(g.teamName(), ev.Score)
 			}
-		}
 
-		// Render the game
-		renderAll(g, g.DefStyle, m)
+			// After a cleared stage the next one is played, and the
+			// menu comes back after the last one
+			if g.mode == Campaign && g.engine.Snapshot().Winner >= 0 {
+				g.stage++
+				if g.stage >= len(g.stages) {
+					g.stage = 0
+					g.Return()
+					continue
+				}
+			}
 
-		// Keep track of FPS
-		g.getFPS()
-		g.frames++
+			// The same goes for the puzzles in a pack
+			if g.mode == Puzzle && g.engine.Snapshot().Winner >= 0 {
+				g.puzzle++
+				if g.puzzle >= len(g.packs[g.pack].Puzzles) {
+					g.puzzle = 0
+					g.Return()
+					continue
+				}
+			}
+			g.Restart()
+		}
 	}
+}
 
-	// If game ends then kill the handlePlayer goroutines
-	for _, p := range g.players {
-		p.QuitChan <- true
+// bell rings the terminal bell if it is turned on in the settings.
+func (g *Game) bell() {
+	if g.settings.Bell {
+		g.screen.Beep()
 	}
+}
 
-	return nil
+// recordScore reads high scores from file, compares them against a player's
+// score and makes changes if necessary.
+func (g *Game) recordScore(name string, score int) {
+	if g.mode == Daily {
+		g.recordDaily(name, score)
+		return
+	}
+	scoreChange := false
+	g.getScores()
+	mode := scoreMode(g.mode)
+	g.scores[mode], scoreChange = UpdateScores(g.scores[mode], name, score, mode, MaxHighScores)
+	if scoreChange {
+		WriteScores(g.scores, g.scoreFile)
+	}
+}
+
+// pollEvents waits for screen events and passes them to the game loop
+// until the game loop is done.
+func (g *Game) pollEvents(done chan bool) {
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		ev := g.screen.PollEvent()
+		if ev == nil {
+			return
+		}
+		select {
+		case g.events <- ev:
+		case <-done:
+			return
+		}
+	}
 }
 
 // Quit completely exits the game back to terminal.
@@ -447,6 +793,11 @@ func (g *Game) Return() {
 
 // Restart resets the game in the same game mode with same players.
 func (g *Game) Restart() {
+	// The daily challenge only gets one attempt
+	if g.mode == Daily {
+		g.Return()
+		return
+	}
 	g.state = Restart
 	logger.Info("Restarting the game...")
 	g.screen.Fini()
@@ -457,10 +808,14 @@ func (g *Game) Restart() {
 // based on input.
 func (g *Game) handleMenu(options []string) int {
 	choice := 0
-	m := NewMainMenu(options, g.DefStyle, g.SelStyle, 0)
+	m := NewMainMenu(options, g.viewWidth, g.viewHeight, g.DefStyle, g.SelStyle, 0)
 	m.SetSelected(0)
 	m.ChangeSelected()
-	for choice == 0 {
+	for choice == ItemNone || choice == ItemResize {
+		if choice == ItemResize {
+			// Rebuild the menu so it is centered on the resized map
+			m = NewMainMenu(options, g.viewWidth, g.viewHeight, g.DefStyle, g.SelStyle, m.GetSelected())
+		}
 		renderMenu(g, m, g.DefStyle)
 		choice = handleMenuInput(g, m)
 	}
@@ -470,176 +825,15 @@ func (g *Game) handleMenu(options []string) int {
 	return choice
 }
 
-// handlePlayer is the player loop and handles a player's
-// state and  interaction with objects and the game map.
-func (g *Game) handlePlayer(p *entity.Player) {
-	defer func() {
-		if r := recover(); r != nil {
-			logger.Errorf("Error in handlePlayer goroutine: %v", r)
-		}
-	}()
-	// Continuously loop unless killed through p.ch channel
-	for {
-		select {
-		default:
-			scoreChange := false
-
-			// Check which direction player should be moving
-			dx, dy := p.CheckDirection()
-
-			// Check if player is blocked at all
-			if p.IsBlocked(m, g.biteMap, g.entities, g.players, dx, dy) {
-				name := p.GetName()
-				score := p.GetScore()
-				// Read high scores from file, compare against current scores
-				// and make changes if necessary
-				g.getScores()
-				g.scores2, scoreChange = UpdateScores(g.scores2, name, score, g.mode, MaxHighScores)
-				if scoreChange {
-					WriteScores(g.scores1, g.scores2, g.scoreFile)
-				}
-
-				// Run if in 2 player mode
-				if g.numPlayers == 1 {
-					// Kill player
-					p.Kill(g.BiteExplodedStyle)
-					logger.Infof("Player died: %v", name)
-					// Wait a short period of time then restart the game
-					time.Sleep(100 * time.Millisecond)
-					g.Restart()
-				} else {
-					g.bits = p.DropBits(g.bits, BitRune, BitRandom, g.DefStyle)
-					p.Reset(MapWidth/2, MapHeight/2, 3, g.BiteExplodedStyle)
-					logger.Infof("Player died: %v", name)
-				}
-
-			} else {
-				// Move player if not blocked
-				p.Move(dx, dy)
-			}
-			// Check if player is on a bit or bite
-			bitPos := g.IsOnBit(p)
-			if bitPos != -1 {
-				p.BitChan <- bitPos
-			}
-			bitePos := g.IsOnBite(p, m)
-			if bitePos != -1 {
-				p.BiteChan <- bitePos
-			}
-			g.IsOnItem(p)
-
-			// Calculate player's speed based on their score.
-			// Movement is done by causing the player goroutine
-			// to sleep for a set amount of time.
-			//p.speed += p.score / 200
-			time.Sleep(g.moveInterval(0, p.GetDirection()))
-
-		// Quit goroutine if signaled
-		case <-p.QuitChan:
-			return
-		}
-	}
-}
-
-// handleBits causes bits on map to move in a random direction in timed intervals.
-func (g *Game) moveBits(m *gamemap.GameMap) {
-	defer func() {
-		if r := recover(); r != nil {
-			logger.Errorf("Error in handleBits goroutine: %v", r)
-		}
-	}()
-	for {
-		select {
-		default:
-			// Move bits in a random direction after a set amount of time
-			for i := range g.bits {
-				state := g.bits[i].GetState()
-				switch state {
-				case BitRandom:
-					g.bits[i].MoveRandom(m)
-				}
-			}
-			// Wait a set amount of time
-			time.Sleep(500 * time.Millisecond)
-
-		// Quit goroutine if signaled
-		case <-m.BitChan:
-			return
-		}
-	}
-}
-
-// handleLevel checks the current score against the current level and
-// changes the level if a certain score is reached.
-func (g *Game) handleLevel(m *gamemap.GameMap) {
-	for _, p := range g.players {
-		score := p.GetScore()
-		name := p.GetName()
-		if score >= Level2 {
-			if g.level < 2 {
-				InitLevel2(g)
-				g.level = 2
-				logger.Info(name + " reached level 2!")
-			}
-		}
-		if score >= Level3 {
-			if g.level < 3 {
-				InitLevel3(g)
-				g.level = 3
-				logger.Info(name + " reached level 3!")
-			}
-		}
-		if score >= Level4 {
-			if g.level < 4 {
-				InitLevel4(g)
-				g.level = 4
-				logger.Info(name + " reached level 4!")
-			}
-		}
-		if score >= Level5 {
-			if g.level < 5 {
-				InitLevel5(g)
-				g.level = 5
-				logger.Info(name + " reached level 5!")
-			}
-		}
-		if score >= Level6 {
-			if g.level < 6 {
-				InitLevel6(g)
-				g.level = 6
-				logger.Info(name + " reached level 6!")
-			}
-		}
-	}
-}
-
-// handlePause controls the game and input during the "paused" state.
+// handlePause renders the "paused" state. The game is not stepped while paused.
 func (g *Game) handlePause() {
-	chQuit := false
-
-	// If pause is called kill the player goroutines
-	for g.state == Pause {
-		if !chQuit {
-			for _, p := range g.players {
-				p.QuitChan <- true
-				chQuit = true
-			}
-		}
-		logger.Info("Pausing game...")
-
-		// Render "PAUSED" to screen
-		renderCenterStr(g.gview, MapWidth, MapHeight-4, g.BitStyle, "PAUSED")
-		g.screen.Show()
-
-		// If unpaused then restart player and bit goroutines
-		if g.state == Play {
-			for _, p := range g.players {
-				go g.handlePlayer(p)
-			}
-			go g.moveBits(m)
-			logger.Info("Resuming game...")
-		}
+	// Render "PAUSED" to screen
+	if g.fits() {
+		renderCenterStr(g.hview, g.viewWidth, g.viewHeight-4, g.BitStyle, "PAUSED")
+	} else {
+		renderCenterStr(g.hview, g.viewWidth, g.viewHeight-4, g.BitStyle, "PAUSED - terminal is too small")
 	}
+	g.screen.Show()
 }
 
 // getFPS tracks variables used to calculate the FPS of the game.
@@ -650,89 +844,52 @@ func (g *Game) getFPS() {
 	})
 }
 
-// moveInterval calculates a player's movement speed. Player speed is created by
-// sleeping the player's loop for a set amount of time. The up and down direction
-// have a decrease in speed in an attempt to even out the direction movement speeds.
-// because of the way the terminal is designed vertical movement is normally much
-// faster than horizontal.
-func (g *Game) moveInterval(speed, direction int) time.Duration {
-	ms := 80 //120
-	switch direction {
-	case entity.DirUp, entity.DirDown:
-		ms = 140 //180
-	}
-	//ms -= (speed / 100)
-	return time.Duration(ms) * time.Millisecond
+// getScores reads scores from the game's scoreFile and stores them in it's
+// scores table for each mode.
+func (g *Game) getScores() {
+	byteData := ReadFile(g.scoreFile)
+	g.scores = DecodeScores(byteData)
+	logger.Infof("Loaded high scores from file: %v", g.scoreFile)
 }
 
-// IsOnBit checks if player is on top of a bit
-func (g *Game) IsOnBit(p *entity.Player) int {
-	i := p.CheckBitPos(g.bits)
-	if i != -1 {
-		b := g.bits[i]
-		points := b.GetPoints()
-		char := p.GetChar(0)
-		style := p.GetStyle(0)
-		p.AddScore(points)
-		p.AddSegment(1, char, style)
-	}
-	return i
+func (g *Game) GetState() int {
+	return g.state
 }
 
-// Determine if player is on a bite and if so trigger explosion
-func (g *Game) IsOnBite(p *entity.Player, m *gamemap.GameMap) int {
-	i := p.CheckBitePos(g.bites)
-	if i != -1 {
-		b := g.bites[i]
-		char := p.GetChar(0)
-		style := p.GetStyle(0)
-		p.AddScore(50)
-		p.AddSegment(4, char, style)
-		go b.ExplodeBite(m, g.biteMap, BiteExplodeRune, g.BiteExplodedStyle, g.DefStyle)
-		return i
-	}
-	return -1
+func (g *Game) GetSeed() int64 {
+	return g.seed
 }
 
-func (g *Game) IsOnItem(p *entity.Player) {
-	i := p.CheckItemPos(g.items)
-	if i != -1 {
-		p.AddItem(g.items[i])
-		g.removeItem(i)
-	}
+func (g *Game) GetNumPlayers() int {
+	return g.numPlayers
 }
 
-func (g *Game) removeItem(i int) {
-	g.items[i] = g.items[len(g.items)-1]
-	g.items[len(g.items)-1] = nil
-	g.items = g.items[:len(g.items)-1]
+func (g *Game) GetMode() int {
+	return g.mode
 }
 
-// getScores reads scores from the game's scoreFile and stores them in it's
-// scores1 and scores2 variables.
-func (g *Game) getScores() {
-	byteData := ReadFile(g.scoreFile)
-	g.scores1, g.scores2 = DecodeScores(byteData)
-	logger.Infof("Loaded high scores from file: %v", g.scoreFile)
+func (g *Game) SetMode(mode int) {
+	g.mode = mode
 }
 
-func (g *Game) GetState() int {
-	return g.state
+func (g *Game) GetPuzzle() (int, int) {
+	return g.pack, g.puzzle
 }
 
-func (g *Game) GetNumPlayers() int {
-	return g.numPlayers
+func (g *Game) SetPuzzle(pack, puzzle int) {
+	g.pack, g.puzzle = pack, puzzle
 }
 
-func (g *Game) GetCurProfiles() []*Profile {
-	return g.curProfiles
+func (g *Game) GetStage() int {
+	return g.stage
+}
+
+func (g *Game) SetStage(stage int) {
+	g.stage = stage
 }
 
-func removeBit(bits []*entity.Bit, i int) []*entity.Bit {
-	bits[i] = bits[len(bits)-1]
-	bits[len(bits)-1] = nil
-	bits = bits[:len(bits)-1]
-	return bits
+func (g *Game) GetCurProfiles() []*Profile {
+	return g.curProfiles
 }
 
 func getCharList(list []rune) []string {
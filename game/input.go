@@ -2,42 +2,38 @@ package game
 
 import (
 	"github.com/gdamore/tcell"
+	"github.com/stjiub/gosnake/engine"
 	"github.com/stjiub/gosnake/entity"
 	"github.com/stjiub/gosnake/gamemap"
-	"github.com/stjiub/gosnake/style"
 )
 
-// Handle main game player input
-func handleInput(g *Game) {
-	// Make adjustments depending on if 1 or 2 player game
-	var p2 *entity.Player
-	p := g.players[0]
-	if len(g.players) > 1 {
-		p2 = g.players[1]
-	} else {
-		p2 = p
-	}
-	// Wait for input events and process
-	ev := g.screen.PollEvent()
+// Handle main game player input. Direction and item input is queued
+// for the next game tick.
+func handleInput(g *Game, ev tcell.Event) {
 	switch ev := ev.(type) {
 	case *tcell.EventKey:
-		// Quit game and return to Main Menu if Escape key pressed
-		if ev.Key() == tcell.KeyEscape {
-			g.Return()
-			return
-
-			// Quit game and close window if terminal window exited
-		} else if ev.Key() == tcell.KeyExit {
+		// Quit game and close window if terminal window exited
+		if ev.Key() == tcell.KeyExit {
 			g.Quit()
 			return
-
-			// Restart game if F1 pressed
-		} else if ev.Key() == tcell.KeyF1 {
+		}
+		switch g.settings.Keys.Game.action(keyName(ev)) {
+		// Quit game and return to Main Menu
+		case "quit":
+			g.Return()
+			return
+		// Restart game
+		case "restart":
 			g.Restart()
 			return
-
-			// Pause game if F12 pressed
-		} else if ev.Key() == tcell.KeyF12 {
+		// Take back a puzzle move
+		case "undo":
+			if g.mode == Puzzle && g.state == Play {
+				g.undoMove()
+			}
+			return
+		// Pause or unpause game
+		case "pause":
 			if g.state == Play {
 				g.state = Pause
 				return
@@ -46,85 +42,44 @@ func handleInput(g *Game) {
 				return
 			}
 		}
-		// Handle player direction. Can use WSAD or Arrow keys
-		// for 1 player. 2 player splits these up with WSAD for
-		// player1 and Arrow keys for player2
-		if ev.Key() == tcell.KeyUp {
-			// Prevent player from turning into themselves
-			dir := p2.GetDirection()
-			if !(dir == entity.DirDown) {
-				p2.SetDirection(entity.DirUp)
-			}
-		}
-		if ev.Key() == tcell.KeyDown {
-			dir := p2.GetDirection()
-			if !(dir == entity.DirUp) {
-				p2.SetDirection(entity.DirDown)
-			}
-		}
-		if ev.Key() == tcell.KeyLeft {
-			dir := p2.GetDirection()
-			if !(dir == entity.DirRight) {
-				p2.SetDirection(entity.DirLeft)
-			}
-		}
-		if ev.Key() == tcell.KeyRight {
-			dir := p2.GetDirection()
-			if !(dir == entity.DirLeft) {
-				p2.SetDirection(entity.DirRight)
-			}
-		}
-		if ev.Rune() == 'w' {
-			dir := p.GetDirection()
-			if !(dir == entity.DirDown) {
-				p.SetDirection(entity.DirUp)
-			}
-		}
-		if ev.Rune() == 's' {
-			dir := p.GetDirection()
-			if !(dir == entity.DirUp) {
-				p.SetDirection(entity.DirDown)
-			}
+		// Ignore player input while paused
+		if g.state != Play {
+			return
 		}
-		if ev.Rune() == 'a' {
-			dir := p.GetDirection()
-			if !(dir == entity.DirRight) {
-				p.SetDirection(entity.DirLeft)
-			}
+		handlePlayerInput(g, ev)
+	case *padEvent:
+		if g.state == Play {
+			handlePadInput(g, ev)
 		}
-		if ev.Rune() == 'd' {
-			dir := p.GetDirection()
-			if !(dir == entity.DirLeft) {
-				p.SetDirection(entity.DirRight)
+	case *tcell.EventResize:
+		g.handleResize()
+	}
+}
+
+// Handle player direction and item input using each player's key
+// bindings. A single player without their own bindings can use the global
+// keys of every player.
+func handlePlayerInput(g *Game, ev *tcell.EventKey) {
+	key := keyName(ev)
+	if g.numPlayers == 1 && (len(g.curProfiles) == 0 || len(g.curProfiles[0].Keys) == 0) {
+		for _, b := range g.settings.Keys.Players {
+			if a := b.action(key); a != "" {
+				g.addInput(0, playerInputs[a])
+				return
 			}
 		}
-		if ev.Rune() == 'f' {
-			p.ActivateItem()
+		return
+	}
+	for i := 0; i < g.numPlayers; i++ {
+		if a := g.playerKeys(i).action(key); a != "" {
+			g.addInput(i, playerInputs[a])
 		}
 	}
 }
 
-// Handle paused game input
-func handlePause(g *Game) {
-	ev := g.screen.PollEvent()
-	switch ev := ev.(type) {
-	case *tcell.EventKey:
-		switch ev.Key() {
-		case tcell.KeyEscape:
-			g.state = Quit
-			return
-		case tcell.KeyExit:
-			g.state = Quit
-			return
-		case tcell.KeyF1:
-			g.screen.Fini()
-			g.state = Restart
-			return
-		case tcell.KeyF12:
-			g.state = Play
-			return
-		}
-	}
+// addInput queues a player's action for the next game tick.
+func (g *Game) addInput(player, action int) {
+	g.inputs = append(g.inputs, engine.Input{Player: player, Action: action})
 }
 
 // Handle main menu input
@@ -136,6 +91,14 @@ func handleMenuInput(g *Game, m *Menu) int {
 		}
 	}
 	ev := g.screen.PollEvent()
+
+	// Controllers navigate menus like the arrow keys and enter
+	if pev, ok := ev.(*padEvent); ok {
+		if pev.action == engine.ActionItem && g.padPlayer >= 0 {
+			g.pads.assign(pev.pad, g.padPlayer)
+		}
+		ev = padKey(pev)
+	}
 	switch ev := ev.(type) {
 	case *tcell.EventKey:
 		if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyExit {
@@ -158,6 +121,11 @@ func handleMenuInput(g *Game, m *Menu) int {
 		} else if ev.Key() == tcell.KeyEnter {
 			return ItemEnter
 		}
+	case *tcell.EventResize:
+		g.layout(true)
+		g.screen.Clear()
+		g.screen.Sync()
+		return ItemResize
 	}
 	return ItemNone
 }
@@ -165,7 +133,7 @@ func handleMenuInput(g *Game, m *Menu) int {
 // Handle profile input
 func handleProfileInput(g *Game, entities []*entity.Entity, oColor, oChar *gamemap.Object, char, color *Menu, cColors []string, rotation int, fgMode bool) (int, int, []string) {
 	var s, cs int
-	var sty tcell.Style
+	var sty gamemap.Style
 	for i := range char.items {
 		if char.items[i].selected {
 			s = i
@@ -207,10 +175,10 @@ func handleProfileInput(g *Game, entities []*entity.Entity, oColor, oChar *gamem
 
 				color.SetSelectOnly(cs - 1)
 				if fgMode {
-					sty = style.StringToStyle(color.items[cs-1].str, cColors[1])
+					sty = gamemap.ColorStyle(color.items[cs-1].str, cColors[1])
 					cColors[0] = color.items[cs-1].str
 				} else {
-					sty = style.StringToStyle(cColors[0], color.items[cs-1].str)
+					sty = gamemap.ColorStyle(cColors[0], color.items[cs-1].str)
 					cColors[1] = color.items[cs-1].str
 				}
 				for i := range entities {
@@ -224,10 +192,10 @@ func handleProfileInput(g *Game, entities []*entity.Entity, oColor, oChar *gamem
 			if cs < (len(color.items) - 1) {
 				color.SetSelectOnly(cs + 1)
 				if fgMode {
-					sty = style.StringToStyle(color.items[cs+1].str, cColors[1])
+					sty = gamemap.ColorStyle(color.items[cs+1].str, cColors[1])
 					cColors[0] = color.items[cs+1].str
 				} else {
-					sty = style.StringToStyle(cColors[0], color.items[cs+1].str)
+					sty = gamemap.ColorStyle(cColors[0], color.items[cs+1].str)
 					cColors[1] = color.items[cs+1].str
 				}
 				for i := range entities {
@@ -0,0 +1,374 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/stjiub/gosnake/engine"
+)
+
+var (
+	keysControls string = "enter = rebind - del = default - left/right = profile - esc = save"
+
+	// Actions that can be bound to a key, in the order they are listed
+	gameActions   = []string{"quit", "restart", "pause", "undo"}
+	playerActions = []string{"up", "down", "left", "right", "item"}
+
+	// Engine input sent for each player action
+	playerInputs = map[string]int{
+		"up":    engine.ActionUp,
+		"down":  engine.ActionDown,
+		"left":  engine.ActionLeft,
+		"right": engine.ActionRight,
+		"item":  engine.ActionItem,
+	}
+)
+
+// Bindings maps an action to the name of the key it is bound to.
+type Bindings map[string]string
+
+// KeyConfig stores the keys used to control the game and each player.
+type KeyConfig struct {
+	Game    Bindings   `json:"game"`
+	Players []Bindings `json:"players"`
+}
+
+// DefaultKeys returns the default key bindings. Player 1 uses WASD and f,
+// player 2 uses the arrow keys and enter, player 3 uses IJKL and o and
+// player 4 uses the number pad.
+func DefaultKeys() KeyConfig {
+	return KeyConfig{
+		Game: Bindings{"quit": "Esc", "restart": "F1", "pause": "F12", "undo": "u"},
+		Players: []Bindings{
+			{"up": "w", "down": "s", "left": "a", "right": "d", "item": "f"},
+			{"up": "Up", "down": "Down", "left": "Left", "right": "Right", "item": "Enter"},
+			{"up": "i", "down": "k", "left": "j", "right": "l", "item": "o"},
+			{"up": "8", "down": "5", "left": "4", "right": "6", "item": "0"},
+		},
+	}
+}
+
+// fill adds the default binding for any action that is not bound.
+func (k *KeyConfig) fill() {
+	def := DefaultKeys()
+	if k.Game == nil {
+		k.Game = Bindings{}
+	}
+	for _, a := range gameActions {
+		if k.Game[a] == "" {
+			k.Game[a] = def.Game[a]
+		}
+	}
+	for i := range def.Players {
+		if i >= len(k.Players) {
+			k.Players = append(k.Players, Bindings{})
+		}
+		if k.Players[i] == nil {
+			k.Players[i] = Bindings{}
+		}
+		for _, a := range playerActions {
+			if k.Players[i][a] == "" {
+				k.Players[i][a] = def.Players[i][a]
+			}
+		}
+	}
+}
+
+// keyGroup is a set of bindings listed on the key bindings screen.
+type keyGroup struct {
+	name    string
+	keys    Bindings
+	actions []string
+	profile *Profile // Profile the bindings belong to, nil for global bindings
+	hidden  bool     // Only used to check for conflicts
+}
+
+// copyBindings returns a copy of b.
+func copyBindings(b Bindings) Bindings {
+	c := make(Bindings, len(b))
+	for a, k := range b {
+		c[a] = k
+	}
+	return c
+}
+
+// action returns the action a key is bound to or "" if it is not bound.
+func (b Bindings) action(key string) string {
+	for a, k := range b {
+		if k == key {
+			return a
+		}
+	}
+	return ""
+}
+
+// keyName returns the name used to bind a key press.
+func keyName(ev *tcell.EventKey) string {
+	if ev.Key() == tcell.KeyRune {
+		if ev.Rune() == ' ' {
+			return "Space"
+		}
+		return string(ev.Rune())
+	}
+	return tcell.KeyNames[ev.Key()]
+}
+
+// playerControls describes a player's keys for the controls bar.
+func playerControls(b Bindings) string {
+	return strings.ToLower(b["up"]+"/"+b["down"]+"/"+b["left"]+"/"+b["right"]) +
+		" = up/down/left/right - " + strings.ToLower(b["item"]) + " = item"
+}
+
+// playerKeys returns the keys used by player i. Players whose profile has
+// its own bindings use them instead of the global bindings.
+func (g *Game) playerKeys(i int) Bindings {
+	if i < len(g.curProfiles) && len(g.curProfiles[i].Keys) > 0 {
+		return g.curProfiles[i].Keys
+	}
+	if i < len(g.settings.Keys.Players) {
+		return g.settings.Keys.Players[i]
+	}
+	return nil
+}
+
+// gameControls describes the keys used during a match for the controls bar.
+func (g *Game) gameControls() string {
+	k := g.settings.Keys
+	return playerControls(g.playerKeys(0)) +
+		" - " + strings.ToLower(k.Game["quit"]) + " = quit" +
+		" - " + strings.ToLower(k.Game["restart"]) + " = restart" +
+		" - " + strings.ToLower(k.Game["pause"]) + " = pause" +
+		" - " + strings.ToLower(k.Game["undo"]) + " = undo (puzzle)"
+}
+
+// keyGroups returns the bindings shown on the key bindings screen. Target 0
+// is the global bindings and any other target is the bindings of profile
+// target-1. A profile without its own bindings starts from the global keys
+// of the player it is playing as.
+func (g *Game) keyGroups(target int) []keyGroup {
+	k := g.settings.Keys
+	game := keyGroup{name: "Game", keys: k.Game, actions: gameActions}
+	if target == 0 {
+		groups := []keyGroup{game}
+		for i, b := range k.Players {
+			groups = append(groups, keyGroup{name: fmt.Sprintf("Player %v", i+1), keys: b, actions: playerActions})
+		}
+		return groups
+	}
+	p := g.profiles[target-1]
+	slot := g.profileSlot(p.Name)
+	keys := p.Keys
+	if len(keys) == 0 {
+		keys = copyBindings(k.Players[slot])
+	}
+	game.hidden = true
+	groups := []keyGroup{game, {name: p.Name, keys: keys, actions: playerActions, profile: p}}
+
+	// Keys are sent to every player, so a profile can't use the keys of
+	// another player or of another current profile
+	for i, b := range k.Players {
+		if i == slot {
+			continue
+		}
+		name := fmt.Sprintf("Player %v", i+1)
+		if i < g.numPlayers && i < len(g.curProfiles) && len(g.curProfiles[i].Keys) > 0 {
+			name, b = g.curProfiles[i].Name, g.curProfiles[i].Keys
+		}
+		groups = append(groups, keyGroup{name: name, keys: b, actions: playerActions, hidden: true})
+	}
+	return groups
+}
+
+// profileSlot returns the player a profile is playing as, or 0 if it is not
+// one of the current players.
+func (g *Game) profileSlot(name string) int {
+	for i := 0; i < g.numPlayers && i < len(g.curProfiles); i++ {
+		if g.curProfiles[i].Name == name {
+			return i
+		}
+	}
+	return 0
+}
+
+// keyConflict returns the group and action already bound to key, not
+// counting the action being rebound, or "" if the key is free.
+func keyConflict(groups []keyGroup, key string, group int, action string) string {
+	for i, grp := range groups {
+		for _, a := range grp.actions {
+			if grp.keys[a] == key && !(i == group && a == action) {
+				return strings.ToLower(grp.name) + " " + a
+			}
+		}
+	}
+	return ""
+}
+
+// bindKey binds an action to key unless the key is already in use.
+func bindKey(groups []keyGroup, key string, group int, action string) string {
+	if c := keyConflict(groups, key, group, action); c != "" {
+		return fmt.Sprintf("%v is already used by %v", key, c)
+	}
+	grp := groups[group]
+	grp.keys[action] = key
+	if grp.profile != nil && len(grp.profile.Keys) == 0 {
+		grp.profile.Keys = grp.keys
+	}
+	return ""
+}
+
+// MenuKeys lets the players rebind the global keys or the keys of a
+// profile. The bindings are saved when the screen is closed.
+func (g *Game) MenuKeys() {
+	g.profiles = DecodeProfiles(ReadFile(g.proFile))
+	target, sel := 0, 0
+	message := ""
+	for {
+		groups := g.keyGroups(target)
+
+		// Every action is a row that can be selected
+		type keyRow struct {
+			group  int
+			action string
+		}
+		var rows []keyRow
+		lines := 0
+		for i, grp := range groups {
+			if grp.hidden {
+				continue
+			}
+			lines++
+			for _, a := range grp.actions {
+				rows = append(rows, keyRow{i, a})
+				lines++
+			}
+		}
+		if sel >= len(rows) {
+			sel = len(rows) - 1
+		}
+
+		// Draw the bindings
+		g.screen.Clear()
+		g.gview.Clear()
+		title := "Key Bindings: Global"
+		if target > 0 {
+			title = "Key Bindings: " + g.profiles[target-1].Name
+			if len(g.profiles[target-1].Keys) == 0 {
+				title += " (using global keys)"
+			}
+		}
+		y := (g.viewHeight - lines - 2) / 2
+		if y < 0 {
+			y = 0
+		}
+		renderCenterStr(g.gview, g.viewWidth, y, g.SelStyle, title)
+		y += 2
+		r := 0
+		for _, grp := range groups {
+			if grp.hidden {
+				continue
+			}
+			renderCenterStr(g.gview, g.viewWidth, y, g.SelStyle, fmt.Sprintf("%-20v", grp.name))
+			y++
+			for _, a := range grp.actions {
+				sty := g.DefStyle
+				if r == sel {
+					sty = g.SelStyleBG
+				}
+				renderCenterStr(g.gview, g.viewWidth, y, sty, fmt.Sprintf("%-10v%10v", a, grp.keys[a]))
+				y++
+				r++
+			}
+		}
+		if message != "" {
+			g.sbar.SetCenter(message, g.BiteExplodedStyle)
+		} else {
+			g.sbar.SetCenter(keysControls, g.DefStyle)
+		}
+		g.sbar.Draw()
+		g.screen.Show()
+		message = ""
+
+		ev := g.screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventKey:
+			row := rows[sel]
+			switch ev.Key() {
+			case tcell.KeyEscape, tcell.KeyExit:
+				WriteSettings(g.settings, g.configFile)
+				if len(g.profiles) > 0 {
+					WriteProfiles(g.profiles, g.proFile)
+				}
+				g.updateCurProfiles()
+				g.controls = g.gameControls()
+				return
+			case tcell.KeyUp:
+				if sel > 0 {
+					sel--
+				}
+			case tcell.KeyDown:
+				if sel < len(rows)-1 {
+					sel++
+				}
+			case tcell.KeyLeft:
+				target = step(target, -1, len(g.profiles)+1)
+			case tcell.KeyRight:
+				target = step(target, 1, len(g.profiles)+1)
+			case tcell.KeyEnter:
+				name := groups[row.group].name
+				g.sbar.SetCenter(fmt.Sprintf("Press a key for %v %v", strings.ToLower(name), row.action), g.SelStyle)
+				g.sbar.Draw()
+				g.screen.Show()
+				if key := g.captureKey(); key != "" {
+					message = bindKey(groups, key, row.group, row.action)
+				}
+			case tcell.KeyDelete, tcell.KeyBackspace, tcell.KeyBackspace2:
+				// Profiles go back to the global keys and global keys go
+				// back to their default
+				if p := groups[row.group].profile; p != nil {
+					p.Keys = nil
+					continue
+				}
+				def := DefaultKeys()
+				key := def.Game[row.action]
+				if row.group > 0 && row.group-1 < len(def.Players) {
+					key = def.Players[row.group-1][row.action]
+				}
+				message = bindKey(groups, key, row.group, row.action)
+			}
+		case *tcell.EventResize:
+			g.layout(true)
+			g.screen.Sync()
+		}
+	}
+}
+
+// captureKey waits for the next key press and returns its name, or "" if
+// the terminal window was closed.
+func (g *Game) captureKey() string {
+	for {
+		ev := g.screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventKey:
+			if ev.Key() == tcell.KeyExit {
+				return ""
+			}
+			return keyName(ev)
+		case *tcell.EventResize:
+			g.layout(true)
+			g.screen.Sync()
+		}
+	}
+}
+
+// updateCurProfiles copies key bindings changed on the key bindings screen
+// to the currently selected profiles.
+func (g *Game) updateCurProfiles() {
+	for _, cur := range g.curProfiles {
+		for _, p := range g.profiles {
+			if p.Name == cur.Name {
+				cur.Keys = p.Keys
+			}
+		}
+	}
+}
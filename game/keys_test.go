@@ -0,0 +1,104 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// keysGame returns a game with the default keys and these profiles:
+// x has no keys of its own, o uses m to go down and y and z play the
+// match if playing is set, z with n to go up.
+func keysGame(playing bool) *Game {
+	own := copyBindings(DefaultKeys().Players[0])
+	own["down"] = "m"
+	z := copyBindings(DefaultKeys().Players[1])
+	z["up"] = "n"
+	g := &Game{settings: DefaultSettings()}
+	g.profiles = []*Profile{{Name: "x"}, {Name: "o", Keys: own}, {Name: "y"}, {Name: "z", Keys: z}}
+	if playing {
+		g.curProfiles, g.numPlayers = g.profiles[2:], 2
+	}
+	return g
+}
+
+func TestBindKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		playing  bool
+		target   int // 0 for the global keys, else the profile
+		group    int
+		action   string
+		key      string
+		conflict string // What the key is used by, empty if it can be bound
+	}{
+		{"free key", false, 0, 1, "up", "z", ""},
+		{"same key", false, 0, 1, "up", "w", ""},
+		{"game key", false, 0, 0, "undo", "z", ""},
+		{"same player", false, 0, 1, "up", "s", "player 1 down"},
+		{"other player", false, 0, 1, "up", "Up", "player 2 up"},
+		{"player on game key", false, 0, 1, "up", "Esc", "game quit"},
+		{"game on player key", false, 0, 0, "pause", "Enter", "player 2 item"},
+
+		// A profile is checked against the hidden game keys MenuKeys uses
+		// and against the keys of every other player
+		{"profile free key", false, 1, 1, "item", "m", ""},
+		{"profile on game key", false, 1, 1, "up", "Esc", "game quit"},
+		{"profile on other player", false, 1, 1, "up", "Up", "player 2 up"},
+		{"profile on own key", false, 2, 1, "up", "m", "o down"},
+		{"profile on global keys it replaces", false, 2, 1, "item", "s", ""},
+
+		// Players with their own profile keys free up their global keys
+		{"other current profile", true, 3, 1, "up", "n", "z up"},
+		{"keys other profile replaced", true, 3, 1, "up", "Up", ""},
+		{"second player", true, 4, 1, "down", "s", "player 1 down"},
+	}
+	for _, test := range tests {
+		g := keysGame(test.playing)
+		groups := g.keyGroups(test.target)
+		msg := bindKey(groups, test.key, test.group, test.action)
+		if test.conflict != "" {
+			if !strings.HasSuffix(msg, "used by "+test.conflict) {
+				t.Errorf("%v: got %q, want a conflict with %v", test.name, msg, test.conflict)
+			}
+			continue
+		}
+		if msg != "" {
+			t.Errorf("%v: got %q, want the key bound", test.name, msg)
+			continue
+		}
+
+		// The new key is saved where the screen came from
+		var keys Bindings
+		switch {
+		case test.target > 0:
+			keys = g.profiles[test.target-1].Keys
+		case test.group == 0:
+			keys = g.settings.Keys.Game
+		default:
+			keys = g.settings.Keys.Players[test.group-1]
+		}
+		if keys[test.action] != test.key {
+			t.Errorf("%v: %v is bound to %q, want %q", test.name, test.action, keys[test.action], test.key)
+		}
+	}
+}
+
+func TestBindKeyCopiesPlayerKeys(t *testing.T) {
+	g := keysGame(true)
+
+	// y plays as player 1 and starts from their keys
+	groups := g.keyGroups(3)
+	if msg := bindKey(groups, "m", 1, "item"); msg != "" {
+		t.Fatalf("got %q binding a free key", msg)
+	}
+	want := copyBindings(DefaultKeys().Players[0])
+	want["item"] = "m"
+	for _, a := range playerActions {
+		if k := g.profiles[2].Keys[a]; k != want[a] {
+			t.Errorf("%v is bound to %q, want %q", a, k, want[a])
+		}
+	}
+	if g.settings.Keys.Players[0]["item"] != "f" {
+		t.Error("binding a profile key changed the global keys")
+	}
+}
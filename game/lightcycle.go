@@ -0,0 +1,38 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Light cycle mode rules
+const (
+	LightCycleRounds = 5
+)
+
+// lightCycleConfig adds the light cycle rules to an engine config. Every
+// snake has a single life each round, so the last one moving wins it.
+func lightCycleConfig(config *engine.Config) {
+	config.Lives = 1
+	config.Rounds = LightCycleRounds
+	config.BestOf = true
+	config.Trails = true
+	config.NumBits = 0
+}
+
+// renderLightCycle draws each player's round wins and the round, and the
+// scoreboard during the break between rounds.
+func renderLightCycle(g *Game, s *engine.Snapshot) {
+	w, h := g.viewWidth, g.viewHeight
+	round := fmt.Sprintf("round %v - best of %v", s.Round, s.Rounds)
+	renderCenterStr(g.hview, w, h-2, g.SelStyle, round)
+	for i, p := range s.Players {
+		l := fmt.Sprintf("%v: %v wins", p.GetName(), s.Stats[i].Wins)
+		x := (w*(2*i+1))/(2*len(s.Players)) - (len(l) / 2)
+		renderStr(g.hview, x, h/2, g.SelStyle, l)
+	}
+	if s.Break > 0 {
+		renderScoreboard(g, s)
+	}
+}
@@ -45,11 +45,11 @@ func NewMenu(items []*MenuItem, defStyle, selStyle tcell.Style) *Menu {
 	return &m
 }
 
-// Create player menu screen
-func NewMainMenu(menuOptions []string, defStyle, selStyle tcell.Style, selected int) *Menu {
+// Create player menu screen centered on a map of the given size
+func NewMainMenu(menuOptions []string, w, h int, defStyle, selStyle tcell.Style, selected int) *Menu {
 	var items []*MenuItem
 	for _, option := range menuOptions {
-		p := NewMenuItem(MapWidth, MapHeight, option, defStyle)
+		p := NewMenuItem(w, h, option, defStyle)
 		items = append(items, p)
 	}
 	m := NewMenu(items, defStyle, selStyle)
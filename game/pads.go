@@ -0,0 +1,136 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/engine"
+	"github.com/stjiub/gosnake/joystick"
+)
+
+var padControls string = "use a controller to pick its player's profile"
+
+// padEvent is posted to the screen when a controller is used.
+type padEvent struct {
+	t      time.Time
+	pad    int // Controller that was used
+	action int // Engine action it produced
+}
+
+func (ev *padEvent) When() time.Time {
+	return ev.t
+}
+
+// Pads reads every connected controller and posts their actions to the
+// game's screen. Controllers are assigned to players in the profile menu.
+type Pads struct {
+	mu      sync.Mutex
+	screen  tcell.Screen
+	names   []string
+	players []int // Player each controller is assigned to, -1 if none
+}
+
+// OpenPads opens every connected controller and starts reading them.
+func OpenPads() *Pads {
+	p := &Pads{}
+	for _, path := range joystick.Scan() {
+		d, err := joystick.Open(path)
+		if err != nil {
+			logger.Errorf("Error opening controller: %v", err)
+			continue
+		}
+		p.names = append(p.names, d.Name)
+		p.players = append(p.players, -1)
+		go p.read(len(p.names)-1, d)
+		logger.Infof("Opened controller: %v", d.Name)
+	}
+	return p
+}
+
+// read posts a controller's actions until it is disconnected.
+func (p *Pads) read(i int, d *joystick.Device) {
+	defer d.Close()
+	for {
+		a, err := d.Read()
+		if err != nil {
+			logger.Errorf("Error reading controller %v: %v", d.Name, err)
+			return
+		}
+		p.mu.Lock()
+		s := p.screen
+		p.mu.Unlock()
+		if s != nil {
+			s.PostEvent(&padEvent{t: time.Now(), pad: i, action: a})
+		}
+	}
+}
+
+// setScreen changes the screen controller actions are posted to.
+func (p *Pads) setScreen(s tcell.Screen) {
+	p.mu.Lock()
+	p.screen = s
+	p.mu.Unlock()
+}
+
+// count returns the number of connected controllers.
+func (p *Pads) count() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.names)
+}
+
+// assign gives a controller to a player, taking it away from anyone else.
+func (p *Pads) assign(pad, player int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.players {
+		if p.players[i] == player {
+			p.players[i] = -1
+		}
+	}
+	p.players[pad] = player
+	logger.Infof("Controller %v assigned to player %v", p.names[pad], player+1)
+}
+
+// player returns the player a controller is assigned to or -1.
+func (p *Pads) player(pad int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.players[pad]
+}
+
+// SetPads sets the controllers used by the game.
+func (g *Game) SetPads(p *Pads) {
+	g.pads = p
+}
+
+// handlePadInput queues a controller's action for the player it is
+// assigned to. A single player can use any controller.
+func handlePadInput(g *Game, ev *padEvent) {
+	p := 0
+	if g.numPlayers > 1 {
+		p = g.pads.player(ev.pad)
+		if p < 0 || p >= g.numPlayers {
+			return
+		}
+	}
+	g.addInput(p, ev.action)
+}
+
+// padKey converts a controller action to the key used to navigate menus.
+func padKey(ev *padEvent) *tcell.EventKey {
+	switch ev.action {
+	case engine.ActionUp:
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case engine.ActionDown:
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case engine.ActionLeft:
+		return tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone)
+	case engine.ActionRight:
+		return tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone)
+	}
+	return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+}
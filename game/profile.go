@@ -7,6 +7,7 @@ import (
 
 	"github.com/gdamore/tcell"
 	"github.com/google/logger"
+	"github.com/stjiub/gosnake/engine"
 	"github.com/stjiub/gosnake/entity"
 	"github.com/stjiub/gosnake/gamemap"
 	"github.com/stjiub/gosnake/style"
@@ -17,6 +18,10 @@ type Profile struct {
 	FGColor string
 	BGColor string
 	Char    rune
+	Keys    Bindings `json:",omitempty"` // Player keys, global keys are used if empty
+
+	Campaign map[string]int `json:",omitempty"` // Stars earned on each campaign stage
+	Puzzles  map[string]int `json:",omitempty"` // Fewest moves each puzzle was solved in
 }
 
 var (
@@ -46,10 +51,7 @@ func (p *Profile) GetStyle() tcell.Style {
 // AssignToPlayer assigns the current profile color to a player.
 func (p *Profile) AssignToPlayer(player *entity.Player) {
 	player.SetName(p.Name)
-	fgColor := tcell.GetColor(p.FGColor)
-	bgColor := tcell.GetColor(p.BGColor)
-	sty := style.GetStyle(bgColor, fgColor)
-	player.SetStyle(sty)
+	player.SetStyle(gamemap.ColorStyle(p.FGColor, p.BGColor))
 }
 
 // DecodeProfiles takes a JSON byte slice and converts it into
@@ -85,7 +87,7 @@ func WriteProfiles(profiles []*Profile, file string) {
 func CreateProfile(g *Game) int {
 	var name string = ""
 	for name == "" {
-		name = GetProfileName(g, MapWidth, MapHeight)
+		name = GetProfileName(g, g.viewWidth, g.viewHeight)
 		if name != "-quit-" {
 			g.gview.Clear()
 			p := NewProfile(name, PlayerColors[0], PlayerColors[1], PlayerRune)
@@ -124,27 +126,27 @@ func RemoveProfile(g *Game, i int) int {
 
 func (p *Profile) Edit(g *Game, chars, colors []string) int {
 	// Create char and color select menus
-	charMenu := NewMainMenu(chars, g.DefStyle, g.SelStyle, 0)
-	colorMenu := NewMainMenu(colors, g.DefStyle, g.DefStyle, 0)
+	charMenu := NewMainMenu(chars, g.viewWidth, g.viewHeight, g.DefStyle, g.SelStyle, 0)
+	colorMenu := NewMainMenu(colors, g.viewWidth, g.viewHeight, g.DefStyle, g.DefStyle, 0)
 
 	// Set general positioning for screen elements
 	l := len(charMenu.items)
-	w := (MapWidth / 2) - l
-	h := (MapHeight / 2) - 8
+	w := (g.viewWidth / 2) - l
+	h := (g.viewHeight / 2) - 8
 
 	// Create color entity to display color selection bar
-	eColor := entity.NewColorEntity(w-2, h+2, BitRune, PlayerColors, g.DefStyle)
+	eColor := entity.NewColorEntity(w-2, h+2, engine.BitRune, PlayerColors, gamemap.DefStyle)
 
 	// Create display entities for each rotation to show current selected attributes on
-	sty := style.StringToStyle(p.FGColor, p.BGColor)
+	sty := gamemap.ColorStyle(p.FGColor, p.BGColor)
 	eDisplayH := entity.NewDisplayEntity(w+12, h+8, 20, 1, 0, p.Char, sty)
 	eDisplayV := entity.NewDisplayEntity(w+l, h+2, 11, 0, 1, p.Char, sty)
 	eDisplayDL := entity.NewDisplayEntity(w+l-6, h+2, 11, 1, 1, p.Char, sty)
 	eDisplayDR := entity.NewDisplayEntity(w+l-5, h+13, 11, 1, -1, p.Char, sty)
 
 	// Create dots to show what attributes are currently selected
-	oChar := gamemap.NewObject(w, h-1, BitRune, g.SelStyle, false)
-	oColor := gamemap.NewObject(w-4, h+2, BitRune, g.SelStyle, false)
+	oChar := gamemap.NewObject(w, h-1, engine.BitRune, gamemap.SelStyle, false)
+	oColor := gamemap.NewObject(w-4, h+2, engine.BitRune, gamemap.SelStyle, false)
 
 	entities := []*entity.Entity{eDisplayH, eDisplayDL, eDisplayV, eDisplayDR}
 	objects := []*gamemap.Object{oColor, oChar}
@@ -157,11 +159,11 @@ func (p *Profile) Edit(g *Game, chars, colors []string) int {
 	// Display editor and handle input
 	for char == ItemNone {
 		g.gview.Clear()
-		renderCenterStr(g.gview, MapWidth, MapHeight/4, g.SelStyle, "Edit Profile")
-		//renderCenterStr(g.sview, MapWidth, 0, g.DefStyle, profileControls)
-		renderObjects(g.gview, objects)
-		renderEntity(g.gview, eColor)
-		renderEntity(g.gview, entities[rotation])
+		renderCenterStr(g.gview, g.viewWidth, g.viewHeight/4, g.SelStyle, "Edit Profile")
+		//renderCenterStr(g.sview, g.viewWidth, 0, g.DefStyle, profileControls)
+		renderObjects(g.gview, &g.Style, objects)
+		renderEntity(g.gview, &g.Style, eColor)
+		renderEntity(g.gview, &g.Style, entities[rotation])
 		renderProfile(g, charMenu, w, h, g.DefStyle)
 		char, rotation, cColors = handleProfileInput(g, entities, oColor, oChar, charMenu, colorMenu, cColors, rotation, fgMode)
 		switch char {
@@ -170,7 +172,7 @@ func (p *Profile) Edit(g *Game, chars, colors []string) int {
 			fgMode = false
 			char = 0
 		case FGMode:
-			eColor.SetChar(BitRune)
+			eColor.SetChar(engine.BitRune)
 			fgMode = true
 			char = 0
 		}
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/engine"
+)
+
+// MenuPuzzle lets the player pick a puzzle pack and then a puzzle from it,
+// showing their best number of moves and the par of each puzzle.
+func (g *Game) MenuPuzzle() int {
+	g.loadPuzzles()
+	p := g.curProfiles[0]
+	for {
+		var packs []string
+		for _, pack := range g.packs {
+			packs = append(packs, pack.Name)
+		}
+		g.gview.Clear()
+		renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+		renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
+		i := g.handleMenu(packs)
+		if i == ItemExit {
+			g.state = MainMenu
+			return MenuMain
+		}
+
+		var puzzles []string
+		for _, pz := range g.packs[i].Puzzles {
+			best := "-"
+			if n, ok := p.Puzzles[puzzleKey(g.packs[i], pz)]; ok {
+				best = fmt.Sprint(n)
+			}
+			puzzles = append(puzzles, fmt.Sprintf("%-16v best %3v   par %3v", pz.Name, best, pz.Par))
+		}
+		g.gview.Clear()
+		renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+		renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
+		j := g.handleMenu(puzzles)
+		if j != ItemExit {
+			g.pack, g.puzzle = i, j
+			return MenuMain
+		}
+	}
+}
+
+// loadPuzzles reads the puzzle packs, falling back to the built in pack.
+func (g *Game) loadPuzzles() {
+	packs, err := engine.LoadPuzzles(g.puzzleDir, g.mapWidth, g.mapHeight)
+	if err != nil {
+		logger.Errorf("Error loading puzzles, using default puzzles: %v", err)
+		packs = engine.DefaultPuzzles()
+	}
+	g.packs = packs
+}
+
+// curPuzzle returns the puzzle being played.
+func (g *Game) curPuzzle() *engine.Puzzle {
+	return g.packs[g.pack].Puzzles[g.puzzle]
+}
+
+// puzzleKey returns the name a puzzle's best score is saved under.
+func puzzleKey(pack *engine.PuzzlePack, pz *engine.Puzzle) string {
+	return pack.Name + "/" + pz.Name
+}
+
+// puzzleConfig adds the puzzle rules to an engine config. The puzzle
+// places its own bits.
+func puzzleConfig(config *engine.Config, pz *engine.Puzzle) {
+	config.StepMoves = true
+	config.MoveLimit = pz.Moves
+	config.Length = pz.Length
+	config.NumBits = 0
+}
+
+// undoMove takes back the last move of a puzzle by playing every move
+// before it again on a fresh engine. The replay is recorded again so it
+// only holds the moves that were kept.
+func (g *Game) undoMove() {
+	if s := g.engine.Snapshot(); len(g.history) == 0 || s.Break > 0 || s.Over {
+		return
+	}
+	g.history = g.history[:len(g.history)-1]
+	config := g.config
+	config.Rand = rand.New(rand.NewSource(g.seed))
+	g.engine = engine.NewEngine(config)
+	g.replay = NewReplay(g.seed, g.mode, config, g.curProfiles)
+	for _, inputs := range g.history {
+		g.replay.Record(g.engine.GetTick()+1, inputs)
+		g.engine.Step(inputs)
+	}
+}
+
+// solvePuzzle saves the number of moves a puzzle was solved in to the
+// player's profile if it is better than before.
+func (g *Game) solvePuzzle(moves int) {
+	key := puzzleKey(g.packs[g.pack], g.curPuzzle())
+	cur := g.curProfiles[0]
+	cur.setBest(key, moves)
+	g.profiles = DecodeProfiles(ReadFile(g.proFile))
+	for _, p := range g.profiles {
+		if p.Name == cur.Name {
+			p.setBest(key, moves)
+		}
+	}
+	WriteProfiles(g.profiles, g.proFile)
+	logger.Infof("%v solved %v in %v moves", cur.Name, key, moves)
+}
+
+// setBest records the moves a puzzle was solved in if it is fewer than the
+// profile's best.
+func (p *Profile) setBest(puzzle string, moves int) {
+	if best, ok := p.Puzzles[puzzle]; ok && best <= moves {
+		return
+	}
+	if p.Puzzles == nil {
+		p.Puzzles = make(map[string]int)
+	}
+	p.Puzzles[puzzle] = moves
+}
+
+// renderPuzzle draws the puzzle, the moves made and left and the bits left
+// to eat, and the result once the puzzle is over.
+func renderPuzzle(g *Game, s *engine.Snapshot) {
+	w, h := g.viewWidth, g.viewHeight
+	if g.pack >= len(g.packs) {
+		return
+	}
+	pz := g.curPuzzle()
+	line := fmt.Sprintf("%v - moves %v/%v - par %v - bits left %v", pz.Name, s.Moves, pz.Moves, pz.Par, len(s.Bits))
+	renderCenterStr(g.hview, w, h-2, g.SelStyle, line)
+
+	if s.Break > 0 {
+		if s.Winner >= 0 {
+			renderBox(g, []string{"Solved!", "", fmt.Sprintf("%v moves - %v", s.Moves, parName(s.Moves, pz.Par))})
+		} else {
+			renderBox(g, []string{"Out of moves"})
+		}
+	}
+}
+
+// parName describes a number of moves compared to par.
+func parName(moves, par int) string {
+	switch {
+	case moves < par:
+		return fmt.Sprintf("%v under par", par-moves)
+	case moves > par:
+		return fmt.Sprintf("%v over par", moves-par)
+	}
+	return "par"
+}
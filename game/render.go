@@ -7,43 +7,65 @@ import (
 	"github.com/gdamore/tcell"
 	"github.com/gdamore/tcell/views"
 	"github.com/mattn/go-runewidth"
+	"github.com/stjiub/gosnake/engine"
 	"github.com/stjiub/gosnake/entity"
 	"github.com/stjiub/gosnake/gamemap"
 	"github.com/stjiub/gosnake/style"
 )
 
 // Render all of the game in main game loop
-func renderAll(g *Game, style tcell.Style, m *gamemap.GameMap) {
+func renderAll(g *Game, style tcell.Style, s *engine.Snapshot) {
 
-	// Clear screen for redraw
-	g.gview.Clear()
-	g.screen.ShowCursor(0, MapHeight)
+	// Clear screen and draw the map through the camera
+	g.screen.ShowCursor(g.viewX, g.viewY+g.viewHeight)
+	renderCamera(g, s)
 
+	// Draw the level and scores in the middle of the screen
+	switch g.rules() {
+	case Battle:
+		renderBattle(g, s)
+	case LightCycle:
+		renderLightCycle(g, s)
+	case TimeAttack:
+		renderTimeAttack(g, s)
+	case Coop:
+		renderCoop(g, s)
+	case Campaign:
+		renderCampaign(g, s)
+	case Puzzle:
+		renderPuzzle(g, s)
+	default:
+		if g.numPlayers == 1 && g.rules() != Basic {
+			renderLevel(g.hview, s.Level, g.viewWidth, g.viewHeight, g.SelStyle)
+		}
+		renderScore(g.hview, s.Players, g.viewWidth, g.viewHeight, g.SelStyle)
+	}
+	renderEffects(g.hview, s.Players, g.viewWidth, g.viewHeight, g.SelStyle)
+	g.sbar.SetCenter(g.controls, g.DefStyle)
+	g.sbar.Draw()
+	g.screen.Show()
+}
+
+// Render everything in a match
+func renderWorld(v views.View, st *style.Style, s *engine.Snapshot) {
 	// Draw game map
-	renderMap(g.gview, m)
+	renderMap(v, st, s.Map)
 
 	// Draw the Bite explosion map
-	renderMap(g.gview, g.biteMap)
+	renderMap(v, st, s.BiteMap)
 
-	if g.numPlayers == 1 {
-		renderLevel(g.gview, g.level, m.Width, m.Height, g.SelStyle)
-	}
-	renderScore(g.gview, g.players, m.Width, m.Height, g.SelStyle)
-	renderBits(g.gview, g.bits)
-	renderBits(g.gview, g.bites)
-	renderItems(g.gview, g.items)
-	renderEntities(g.gview, g.entities)
-	renderPlayers(g.gview, g.players)
-	g.sbar.SetCenter(controls, g.DefStyle)
-	g.sbar.Draw()
-	g.screen.Show()
+	renderBits(v, st, s.Bits)
+	renderBits(v, st, s.Bites)
+	renderItems(v, st, s.Items)
+	renderEntities(v, st, s.Entities)
+	renderPlayers(v, st, s.Players)
 }
 
 // Render the game map
-func renderMap(v *views.ViewPort, m *gamemap.GameMap) {
+func renderMap(v views.View, st *style.Style, m *gamemap.GameMap) {
 	for x := 0; x < m.Width; x++ {
 		for y := 0; y < m.Height; y++ {
-			renderRune(v, x, y, m.Objects[x][y].GetStyle(), m.Objects[x][y].GetChar())
+			renderRune(v, x, y, st.Get(m.Objects[x][y].GetStyle()), m.Objects[x][y].GetChar())
 		}
 	}
 }
@@ -69,123 +91,158 @@ func renderNameSelect(g *Game, w, h int, hStr, charStr string) {
 	g.screen.Show()
 }
 
-// Render the High Score screen
-func renderHighScoreScreen(g *Game, style tcell.Style, max int) {
+// Render the High Score screen for a mode. The daily challenge scores are
+// shown for the given date.
+func renderHighScoreScreen(g *Game, style tcell.Style, mode int, date string) {
 	g.gview.Clear()
 	g.gview.Fill(' ', style)
-	renderCenterStr(g.gview, MapWidth, 4, style, "High Scores")
-	renderCenterStr(g.gview, MapWidth, 6, style, strings.Repeat("=", MapWidth-10))
-	renderCenterStr(g.gview, MapWidth, 10, style, "1 Player:")
-	renderHighScores(g, Player1, 14)
-	renderCenterStr(g.gview, MapWidth, (16 + max*2), style, "2 Player:")
-	renderHighScores(g, Player2, (20 + max*2))
+	renderCenterStr(g.gview, g.viewWidth, 4, style, "High Scores")
+	renderCenterStr(g.gview, g.viewWidth, 6, style, strings.Repeat("=", g.viewWidth-10))
+	renderCenterStr(g.gview, g.viewWidth, 10, style, "< "+modeNames[mode]+" >")
+	if mode == Daily {
+		renderDailyScores(g, style, date, 14)
+		g.sbar.SetCenter("left/right = change mode - up/down = change day - esc = return", g.DefStyle)
+	} else {
+		renderHighScores(g, mode, 14)
+		g.sbar.SetCenter("left/right = change mode - esc = return", g.DefStyle)
+	}
+	g.sbar.Draw()
 
 	g.screen.Show()
 }
 
 // Render a list of scores for the high score screen
 func renderHighScores(g *Game, mode, lastScorePos int) {
-	var scores []*Score
-	if mode == Player1 {
-		scores = g.scores1
-	} else if mode == Player2 {
-		scores = g.scores2
-	}
+	scores := g.scores[mode]
 	for i := range scores {
 		if i < len(scores) {
-			renderCenterStr(g.gview, MapWidth, lastScorePos+i, g.SelStyle, (scores[i].Name + " - " + strconv.Itoa(scores[i].Score)))
+			renderCenterStr(g.gview, g.viewWidth, lastScorePos+i, g.SelStyle, (scores[i].Name + " - " + strconv.Itoa(scores[i].Score)))
 		} else {
-			renderCenterStr(g.gview, MapWidth, lastScorePos+i, g.DefStyle, "----")
+			renderCenterStr(g.gview, g.viewWidth, lastScorePos+i, g.DefStyle, "----")
 		}
 		lastScorePos++
 	}
 }
 
-// Render the player scores in middle of screen
-func renderScore(v *views.ViewPort, players []*entity.Player, w, h int, style tcell.Style) {
+// Render the player scores in middle of screen. Each player's score is
+// centered in their own slot across the screen.
+func renderScore(v views.View, players []*entity.Player, w, h int, style tcell.Style) {
 	scores := ""
 	for i := range players {
 		scores = players[i].GetName() + ": " + strconv.Itoa(players[i].GetScore()) + " "
-		renderCenterStr(v, w, h+i, style, scores)
+		x := (w*(2*i+1))/(2*len(players)) - (len(scores) / 2)
+		renderStr(v, x, h/2, style, scores)
+	}
+}
+
+// Render the items each player holds and the effects working on them with
+// the time they have left, under the player's score.
+func renderEffects(v views.View, players []*entity.Player, w, h int, style tcell.Style) {
+	for i, p := range players {
+		var parts []string
+		if items := p.GetItems(); len(items) > 0 {
+			held := ""
+			for _, it := range items {
+				held += string(it.GetChar())
+			}
+			parts = append(parts, "["+held+"]")
+		}
+		for _, a := range p.GetEffects() {
+			name := entity.Effects[a.Effect].Name
+			if a.Effect == entity.Multiplier {
+				name = "x" + strconv.Itoa(a.Count+1)
+			}
+			parts = append(parts, name+" "+clock(a.Remaining))
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		str := strings.Join(parts, " ")
+		x := (w*(2*i+1))/(2*len(players)) - (len(str) / 2)
+		renderStr(v, x, h/2+2, style, str)
 	}
 }
 
 // Render the current level in middle of screen
-func renderLevel(v *views.ViewPort, l, w, h int, style tcell.Style) {
+func renderLevel(v views.View, l, w, h int, style tcell.Style) {
 	level := "level: " + strconv.Itoa(l)
 	renderCenterStr(v, w, h-2, style, level)
 }
 
 // Render a Player
-func renderPlayer(v *views.ViewPort, p *entity.Player) {
+func renderPlayer(v views.View, st *style.Style, p *entity.Player) {
 	for i := 0; i < p.GetLength(); i++ {
 		var comb []rune
 		comb = nil
 		c := p.GetChar(i)
 		x, y := p.GetCurPos(i)
-		sty := p.GetStyle(i)
+		sty := st.Get(p.GetStyle(i))
 		v.SetContent(x, y, c, comb, sty)
 	}
 }
 
 // Render an Entity
-func renderEntity(v *views.ViewPort, e *entity.Entity) {
+func renderEntity(v views.View, st *style.Style, e *entity.Entity) {
 	for i := 0; i < e.GetLength(); i++ {
 		var comb []rune
 		comb = nil
 		c := e.GetChar(i)
 		x, y := e.GetCurPos(i)
-		sty := e.GetStyle(i)
+		sty := st.Get(e.GetStyle(i))
 		v.SetContent(x, y, c, comb, sty)
 	}
 
 }
 
 // Render all Entities
-func renderEntities(v *views.ViewPort, entities []*entity.Entity) {
+func renderEntities(v views.View, st *style.Style, entities []*entity.Entity) {
 	for i := range entities {
-		renderEntity(v, entities[i])
+		renderEntity(v, st, entities[i])
 	}
 }
 
 // Render all Players
-func renderPlayers(v *views.ViewPort, players []*entity.Player) {
+func renderPlayers(v views.View, st *style.Style, players []*entity.Player) {
 	for i := range players {
-		renderPlayer(v, players[i])
+		// Players that are out of the game are not drawn
+		if players[i].IsGone() {
+			continue
+		}
+		renderPlayer(v, st, players[i])
 	}
 }
 
 // Render all objects
-func renderObjects(v *views.ViewPort, objects []*gamemap.Object) {
+func renderObjects(v views.View, st *style.Style, objects []*gamemap.Object) {
 	for i := range objects {
 		x, y := objects[i].GetCurPos()
 		char := objects[i].GetChar()
-		sty := objects[i].GetStyle()
+		sty := st.Get(objects[i].GetStyle())
 		renderRune(v, x, y, sty, char)
 	}
 }
 
 // Render all Bits
-func renderBits(v *views.ViewPort, bits []*entity.Bit) {
+func renderBits(v views.View, st *style.Style, bits []*entity.Bit) {
 	for i := range bits {
 		x, y := bits[i].GetCurPos()
 		char := bits[i].GetChar()
-		sty := bits[i].GetStyle()
+		sty := st.Get(bits[i].GetStyle())
 		renderRune(v, x, y, sty, char)
 	}
 }
 
-func renderItems(v *views.ViewPort, items []*entity.Item) {
+func renderItems(v views.View, st *style.Style, items []*entity.Item) {
 	for i := range items {
 		x, y := items[i].GetCurPos()
 		char := items[i].GetChar()
-		sty := items[i].GetStyle()
+		sty := st.Get(items[i].GetStyle())
 		renderRune(v, x, y, sty, char)
 	}
 }
 
 // Render a string at given position
-func renderStr(v *views.ViewPort, x, y int, style tcell.Style, str string) {
+func renderStr(v views.View, x, y int, style tcell.Style, str string) {
 	for _, c := range str {
 		var comb []rune
 		w := runewidth.RuneWidth(c)
@@ -200,14 +257,14 @@ func renderStr(v *views.ViewPort, x, y int, style tcell.Style, str string) {
 }
 
 // Render a string in the center of the screen
-func renderCenterStr(v *views.ViewPort, w, h int, style tcell.Style, str string) {
+func renderCenterStr(v views.View, w, h int, style tcell.Style, str string) {
 	x := (w / 2) - (len(str) / 2)
 	y := (h / 2)
 	renderStr(v, x, y, style, str)
 }
 
 // Render a string at given position
-func renderChars(v *views.ViewPort, m *Menu, x, y int) {
+func renderChars(v views.View, m *Menu, x, y int) {
 	for _, i := range m.items {
 		for _, c := range i.str {
 			var comb []rune
@@ -224,7 +281,7 @@ func renderChars(v *views.ViewPort, m *Menu, x, y int) {
 }
 
 // Render a single rune to the screen
-func renderRune(v *views.ViewPort, x, y int, style tcell.Style, char rune) {
+func renderRune(v views.View, x, y int, style tcell.Style, char rune) {
 	var comb []rune
 	comb = nil
 	v.SetContent(x, y, char, comb, style)
@@ -0,0 +1,251 @@
+package game
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Replay playback speeds
+var replaySpeeds = []int{1, 2, 4, 8}
+
+// Replay stores everything needed to play a match back exactly as it
+// happened. The engine config holds every rule of the match, and its
+// random source is made again from Seed. Inputs are stored as
+// [tick, player, action] triples.
+type Replay struct {
+	Seed     int64         `json:"seed"`
+	Mode     int           `json:"mode"`
+	Config   engine.Config `json:"config"`
+	Profiles []*Profile    `json:"profiles"`
+	Ticks    int           `json:"ticks"`
+	Inputs   [][3]int      `json:"inputs"`
+}
+
+// NewReplay creates an empty Replay for a match with the given config.
+func NewReplay(seed int64, mode int, config engine.Config, profiles []*Profile) *Replay {
+	r := Replay{
+		Seed:     seed,
+		Mode:     mode,
+		Config:   config,
+		Profiles: profiles,
+	}
+	return &r
+}
+
+// engineConfig returns the recorded config with a fresh random source, so
+// the match starts over exactly as it was recorded.
+func (r *Replay) engineConfig() engine.Config {
+	config := r.Config
+	config.Rand = rand.New(rand.NewSource(r.Seed))
+	return config
+}
+
+// Record adds the inputs that were applied on a tick to the Replay.
+func (r *Replay) Record(tick int, inputs []engine.Input) {
+	for _, in := range inputs {
+		r.Inputs = append(r.Inputs, [3]int{tick, in.Player, in.Action})
+	}
+	r.Ticks = tick
+}
+
+// step plays the inputs recorded for the next tick on e. next is the index
+// of the first input that has not been played yet, the index after the
+// inputs that were played is returned.
+func (r *Replay) step(e *engine.Engine, next int) int {
+	tick := e.GetTick() + 1
+	var inputs []engine.Input
+	for ; next < len(r.Inputs) && r.Inputs[next][0] <= tick; next++ {
+		inputs = append(inputs, engine.Input{Player: r.Inputs[next][1], Action: r.Inputs[next][2]})
+	}
+	e.Step(inputs)
+	return next
+}
+
+// EncodeReplay converts a Replay to gzipped JSON.
+func EncodeReplay(r *Replay) ([]byte, error) {
+	var buf bytes.Buffer
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeReplay converts gzipped JSON back into a Replay.
+func DecodeReplay(byteValue []byte) (*Replay, error) {
+	var r Replay
+	zr, err := gzip.NewReader(bytes.NewReader(byteValue))
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// validate checks that a decoded Replay can be played back, so a corrupt or
+// old file is refused instead of breaking the engine.
+func (r *Replay) validate() error {
+	c := r.Config
+	if len(r.Profiles) == 0 {
+		return fmt.Errorf("replay has no players")
+	}
+	if len(c.Players) != len(r.Profiles) {
+		return fmt.Errorf("replay has %v profiles for %v players", len(r.Profiles), len(c.Players))
+	}
+	if c.Width < MinMapWidth || c.Height < MinMapHeight {
+		return fmt.Errorf("replay map size %vx%v is smaller than %vx%v", c.Width, c.Height, MinMapWidth, MinMapHeight)
+	}
+	if len(c.Levels) > 0 {
+		if err := engine.ValidateLevels(c.Levels, c.Width, c.Height); err != nil {
+			return fmt.Errorf("replay levels: %v", err)
+		}
+	}
+	tick := 1
+	for _, in := range r.Inputs {
+		if in[0] < tick || in[0] > r.Ticks {
+			return fmt.Errorf("replay input at tick %v is out of order", in[0])
+		}
+		if in[1] < 0 || in[1] >= len(c.Players) {
+			return fmt.Errorf("replay input at tick %v has unknown player %v", in[0], in[1])
+		}
+		if in[2] < engine.ActionUp || in[2] > engine.ActionItem {
+			return fmt.Errorf("replay input at tick %v has unknown action %v", in[0], in[2])
+		}
+		tick = in[0]
+	}
+	return nil
+}
+
+// WriteReplay saves a Replay to a new timestamped file in dir.
+func WriteReplay(r *Replay, dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Errorf("Error creating replay directory: %v", err)
+		return
+	}
+	data, err := EncodeReplay(r)
+	if err != nil {
+		logger.Errorf("Error encoding replay: %v", err)
+		return
+	}
+	file := filepath.Join(dir, time.Now().Format("20060102-150405")+".replay")
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		logger.Errorf("Error writing replay: %v", err)
+		return
+	}
+	logger.Infof("Saved replay: %v", file)
+}
+
+// ReadReplay loads a Replay from file.
+func ReadReplay(file string) (*Replay, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeReplay(data)
+}
+
+// PlayReplay plays a recorded match back through the renderer. Playback can
+// be paused, stepped a tick at a time, sped up and rewound to the start.
+func (g *Game) PlayReplay(file string) error {
+	r, err := ReadReplay(file)
+	if err != nil {
+		return err
+	}
+
+	// Set the game up the way it was recorded
+	g.seed = r.Seed
+	g.mode = r.Mode
+	g.numPlayers = len(r.Profiles)
+	g.curProfiles = r.Profiles
+	g.mapWidth, g.mapHeight = r.Config.Width, r.Config.Height
+	g.layout(false)
+
+	g.events = make(chan tcell.Event, 16)
+	done := make(chan bool)
+	defer close(done)
+	go g.pollEvents(done)
+
+	ticker := time.NewTicker(engine.TickDuration)
+	defer ticker.Stop()
+
+	g.engine = engine.NewEngine(r.engineConfig())
+	g.state = Play
+	next := 0
+	speed := 0
+	step := false
+
+	for g.state == Play || g.state == Pause {
+		select {
+		case ev := <-g.events:
+			switch ev := ev.(type) {
+			case *tcell.EventResize:
+				g.handleResize()
+			case *tcell.EventKey:
+				switch {
+				case ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyExit:
+					g.state = Quit
+				case ev.Key() == tcell.KeyF12 || ev.Rune() == ' ':
+					if g.state == Play {
+						g.state = Pause
+					} else if g.engine.GetTick() < r.Ticks {
+						g.state = Play
+					}
+				case ev.Key() == tcell.KeyRight || ev.Rune() == '.':
+					g.state = Pause
+					step = true
+				case ev.Rune() == 'f':
+					speed = (speed + 1) % len(replaySpeeds)
+				case ev.Rune() == 'r':
+					g.engine = engine.NewEngine(r.engineConfig())
+					next = 0
+				}
+			}
+		case <-ticker.C:
+			steps := replaySpeeds[speed]
+			if g.state == Pause {
+				steps = 0
+				if step {
+					steps = 1
+					step = false
+				}
+			}
+			for i := 0; i < steps && g.engine.GetTick() < r.Ticks; i++ {
+				next = r.step(g.engine, next)
+			}
+			if g.engine.GetTick() >= r.Ticks {
+				g.state = Pause
+			}
+			g.controls = fmt.Sprintf("space = pause - . = step - f = speed (%vx) - r = rewind - esc = quit - tick %v/%v",
+				replaySpeeds[speed], g.engine.GetTick(), r.Ticks)
+			renderAll(g, g.DefStyle, g.engine.Snapshot())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+// record plays a two player match with the same inputs every time and
+// returns the Replay of it along with the final state.
+func record(seed int64, ticks int) (*Replay, *engine.Snapshot) {
+	config := engine.Config{
+		Width:   DefaultMapWidth,
+		Height:  DefaultMapHeight,
+		Mode:    Advanced,
+		NumBits: 5,
+		Players: []engine.PlayerConfig{
+			{Name: "a", FGColor: "white", BGColor: "black", Char: 'a'},
+			{Name: "b", FGColor: "red", BGColor: "black", Char: 'b'},
+		},
+		Rand:   rand.New(rand.NewSource(seed)),
+		Levels: engine.DefaultLevels(),
+	}
+	profiles := []*Profile{{Name: "a"}, {Name: "b"}}
+	r := NewReplay(seed, Advanced, config, profiles)
+	e := engine.NewEngine(config)
+	for i := 1; i <= ticks; i++ {
+		var inputs []engine.Input
+		if i%40 == 0 {
+			inputs = append(inputs, engine.Input{Player: i / 40 % 2, Action: i / 80 % 4})
+		}
+		r.Record(e.GetTick()+1, inputs)
+		e.Step(inputs)
+	}
+	return r, e.Snapshot()
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	r, live := record(7, 2000)
+	data, err := EncodeReplay(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = DecodeReplay(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := engine.NewEngine(r.engineConfig())
+	next := 0
+	for e.GetTick() < r.Ticks {
+		next = r.step(e, next)
+	}
+	if next != len(r.Inputs) {
+		t.Fatalf("played %v of %v inputs", next, len(r.Inputs))
+	}
+	if !reflect.DeepEqual(e.Snapshot(), live) {
+		t.Fatal("the replay ended in a different state than the match")
+	}
+}
+
+func TestDecodeReplayRefusesBadReplays(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r *Replay)
+		want   string
+	}{
+		{"no profiles", func(r *Replay) { r.Profiles = nil }, "no players"},
+		{"missing player", func(r *Replay) { r.Config.Players = r.Config.Players[:1] }, "profiles"},
+		{"small map", func(r *Replay) { r.Config.Width = MinMapWidth - 1 }, "map size"},
+		{"bad levels", func(r *Replay) { r.Config.Levels[1].Score = 0 }, "levels"},
+		{"tick out of order", func(r *Replay) { r.Inputs[1][0] = 0 }, "out of order"},
+		{"tick after the end", func(r *Replay) { r.Ticks = r.Inputs[0][0] - 1 }, "out of order"},
+		{"unknown player", func(r *Replay) { r.Inputs[0][1] = 2 }, "unknown player"},
+		{"unknown action", func(r *Replay) { r.Inputs[0][2] = engine.ActionItem + 1 }, "unknown action"},
+	}
+	for _, tt := range tests {
+		r, _ := record(7, 200)
+		tt.change(r)
+		data, err := EncodeReplay(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeReplay(data); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
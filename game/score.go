@@ -14,6 +14,7 @@ type Score struct {
 	Name  string `json:"name"`
 	Mode  int    `json:"mode"`
 	Score int    `json:"score"`
+	Date  string `json:"date,omitempty"` // Day of a daily challenge score
 }
 
 // NewScore creates a new Score struct with provided values.
@@ -96,6 +97,7 @@ func UpdateScores(scores []*Score, pName string, pScore, mode, max int) ([]*Scor
 				scores = AddScore(scores, s)
 
 				// Clean up the scores list if longer than max
+				scores = SortScores(scores)
 				if len(scores) > max {
 					scores = RemoveScores(scores, max)
 				}
@@ -118,52 +120,43 @@ func UpdateScores(scores []*Score, pName string, pScore, mode, max int) ([]*Scor
 }
 
 // DecodeScores takes a byteValue from a JSON file and converts it into
-// Score structs and assigns to proper slices
-func DecodeScores(byteValue []byte) ([]*Score, []*Score) {
+// Score structs grouped into one high score table per mode.
+func DecodeScores(byteValue []byte) map[int][]*Score {
 	var scores []*Score
-	var newScores1 []*Score
-	var newScores2 []*Score
+	tables := make(map[int][]*Score)
 
 	// Read the JSON byteData into Score structs
 	json.Unmarshal(byteValue, &scores)
 	listScores := GetScores(scores)
 	logger.Infof("Decode scores: %v", listScores)
 
-	// Separate Scores into the proper slices
-	if scores != nil {
-		for i := range scores {
-			if scores[i].Mode == Player1 {
-				newScores1 = append(newScores1, scores[i])
-			} else if scores[i].Mode == Player2 {
-				newScores2 = append(newScores2, scores[i])
-				listScores = GetScores(newScores2)
-				logger.Infof("newScores2 scores: %v", listScores)
-			}
-		}
+	// Separate Scores into their mode's table
+	for i := range scores {
+		mode := scoreMode(scores[i].Mode)
+		tables[mode] = append(tables[mode], scores[i])
 	}
-	return newScores1, newScores2
+	return tables
 }
 
-// EncodeScores takes the two score slices, combines them into one
-// slice to be marshalled back into byteData and returned to be
-// written to a file.
-func EncodeScores(newScores1, newScores2 []*Score) []byte {
-	var scores []*Score
-
-	newScores1 = SortScores(newScores1)
-	newScores2 = SortScores(newScores2)
-	for _, score := range newScores1 {
-		scores = append(scores, score)
+// EncodeScores combines the high score tables into one slice to be
+// marshalled back into byteData and returned to be written to a file.
+func EncodeScores(tables map[int][]*Score) []byte {
+	var modes []int
+	for mode := range tables {
+		modes = append(modes, mode)
 	}
-	for _, score := range newScores2 {
-		scores = append(scores, score)
+	sort.Ints(modes)
+
+	scores := []*Score{}
+	for _, mode := range modes {
+		scores = append(scores, SortScores(tables[mode])...)
 	}
 	file, _ := json.MarshalIndent(scores, "", " ")
 	return file
 }
 
 // WriteScores opens a JSON file and writes scores to it.
-func WriteScores(newScores1, newScores2 []*Score, file string) {
+func WriteScores(tables map[int][]*Score, file string) {
 	f, err := os.OpenFile(file, os.O_CREATE, 0660)
 	if err != nil {
 		logger.Errorf("Error creating new file: %v", err)
@@ -173,10 +166,19 @@ func WriteScores(newScores1, newScores2 []*Score, file string) {
 		logger.Errorf("Error closing file: %v", err)
 	}
 
-	data := EncodeScores(newScores1, newScores2)
+	data := EncodeScores(tables)
 	_ = ioutil.WriteFile(file, data, 0644)
 }
 
+// scoreMode returns the high score table a mode's scores are kept in.
+// Scores from before the mode could be picked were played as Advanced.
+func scoreMode(mode int) int {
+	if mode == Player1 || mode == Player2 {
+		return Advanced
+	}
+	return mode
+}
+
 // GetScores is used to make a list of all the current scores to view
 // for debugging purposes
 func GetScores(scores []*Score) []int {
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeScores(t *testing.T) {
+	// Modes 0 and 1 are the old Player1 and Player2 modes, 3 is Basic, 4 is
+	// Advanced and 5 is Time Attack
+	tables := DecodeScores([]byte(`[
+		{"name": "a", "mode": 0, "score": 5},
+		{"name": "b", "mode": 1, "score": 9},
+		{"name": "c", "mode": 4, "score": 7},
+		{"name": "d", "mode": 3, "score": 2},
+		{"name": "e", "mode": 5, "score": 3}
+	]`))
+	want := map[int][]string{
+		Advanced:   {"a", "b", "c"},
+		Basic:      {"d"},
+		TimeAttack: {"e"},
+	}
+	if len(tables) != len(want) {
+		t.Fatalf("got %v tables, want %v", len(tables), len(want))
+	}
+	for mode, names := range want {
+		var got []string
+		for _, s := range tables[mode] {
+			got = append(got, s.Name)
+		}
+		if !reflect.DeepEqual(got, names) {
+			t.Errorf("%v table has %v, want %v", modeNames[mode], got, names)
+		}
+	}
+}
+
+func TestScoreMode(t *testing.T) {
+	tests := []struct {
+		mode, want int
+	}{
+		{Player1, Advanced},
+		{Player2, Advanced},
+		{Advanced, Advanced},
+		{Basic, Basic},
+		{Daily, Daily},
+	}
+	for _, tt := range tests {
+		if got := scoreMode(tt.mode); got != tt.want {
+			t.Errorf("scoreMode(%v) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateScores(t *testing.T) {
+	tables := make(map[int][]*Score)
+	for i := 1; i <= MaxHighScores+2; i++ {
+		tables[Basic], _ = UpdateScores(tables[Basic], "a", i*10, Basic, MaxHighScores)
+	}
+	tables[Battle], _ = UpdateScores(tables[Battle], "b", 5, Battle, MaxHighScores)
+	if _, changed := UpdateScores(tables[Basic], "c", 10, Basic, MaxHighScores); changed {
+		t.Error("a score lower than every high score was added")
+	}
+	if _, changed := UpdateScores(tables[Battle], "c", 0, Battle, MaxHighScores); changed {
+		t.Error("a score of 0 was added")
+	}
+
+	// Each table survives being saved and loaded on its own
+	tables = DecodeScores(EncodeScores(tables))
+	if got := GetScores(tables[Basic]); !reflect.DeepEqual(got, []int{70, 60, 50, 40, 30}) {
+		t.Errorf("Basic table is %v", got)
+	}
+	if got := tables[Battle]; len(got) != 1 || got[0].Name != "b" || got[0].Mode != Battle {
+		t.Errorf("Battle table is %v", got)
+	}
+	if len(tables[Advanced]) != 0 {
+		t.Errorf("Advanced table is %v", GetScores(tables[Advanced]))
+	}
+}
@@ -0,0 +1,234 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/google/logger"
+	"github.com/stjiub/gosnake/engine"
+	"github.com/stjiub/gosnake/style"
+)
+
+var (
+	settingsControls string = "up/down = select - left/right/enter = change - esc = save and return"
+
+	// Values that can be picked in the settings menu
+	speedOptions   = []int{50, 75, 100, 125, 150, 175, 200}
+	bitOptions     = []int{1, 3, 5, 10, 20, 50}
+	mapSizeOptions = []string{"auto", "60x24", "80x30", "100x35", "150x50", "200x70", "300x120"}
+	cameraOptions  = []string{"shared", "split"}
+	wallOptions    = []string{"kill", "wrap", "open"}
+)
+
+// Settings stores the options picked in the settings menu.
+type Settings struct {
+	Speed       int       `json:"speed"`        // Snake speed as a percentage of the normal speed
+	NumBits     int       `json:"bits"`         // Number of random bits on the map at a time
+	MapSize     string    `json:"map_size"`     // Map size, WIDTHxHEIGHT or auto
+	Camera      string    `json:"camera"`       // Camera for 2 players, shared or split
+	Theme       string    `json:"theme"`        // Color theme
+	Bell        bool      `json:"bell"`         // Ring the terminal bell on deaths and level ups
+	Wrap        bool      `json:"wrap"`         // Snakes wrap around the map edge instead of dying
+	OpenBorder  bool      `json:"open_border"`  // The map has no border and everything wraps around
+	PassThrough bool      `json:"pass_through"` // Co-op teammates can move through each other
+	Keys        KeyConfig `json:"keys"`         // Key bindings
+}
+
+// DefaultSettings returns the settings used when there is no config file.
+func DefaultSettings() *Settings {
+	s := Settings{
+		Speed:   100,
+		NumBits: 5,
+		MapSize: fmt.Sprintf("%vx%v", DefaultMapWidth, DefaultMapHeight),
+		Camera:  "shared",
+		Theme:   style.Themes[0],
+		Keys:    DefaultKeys(),
+	}
+	return &s
+}
+
+// ReadSettings reads settings from a JSON file. Missing or invalid values
+// use the defaults.
+func ReadSettings(file string) *Settings {
+	s := DefaultSettings()
+	byteData := ReadFile(file)
+	if len(byteData) > 0 {
+		if err := json.Unmarshal(byteData, s); err != nil {
+			logger.Errorf("Error reading settings, using defaults: %v", err)
+			s = DefaultSettings()
+		}
+	}
+	if s.Speed <= 0 {
+		s.Speed = 100
+	}
+	if s.NumBits <= 0 {
+		s.NumBits = 5
+	}
+	s.Keys.fill()
+	return s
+}
+
+// WriteSettings writes settings to a JSON file.
+func WriteSettings(s *Settings, file string) {
+	data, _ := json.MarshalIndent(s, "", " ")
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		logger.Errorf("Error writing settings: %v", err)
+	}
+}
+
+// moveTimes returns how long a snake takes to move one cell sideways and
+// up or down at the chosen speed.
+func (s *Settings) moveTimes() (time.Duration, time.Duration) {
+	h := engine.DefaultHorizontalMove * 100 / time.Duration(s.Speed)
+	v := engine.DefaultVerticalMove * 100 / time.Duration(s.Speed)
+	return h, v
+}
+
+// applySettings applies the settings that change the screen.
+func (g *Game) applySettings() {
+	if err := g.SetMapSize(g.settings.MapSize); err != nil {
+		logger.Errorf("Error setting map size: %v", err)
+	}
+	if err := g.SetCamera(g.settings.Camera); err != nil {
+		logger.Errorf("Error setting camera: %v", err)
+	}
+	g.SetTheme(g.settings.Theme)
+	g.controls = g.gameControls()
+}
+
+// MenuSettings displays the settings and lets the player change them. The
+// settings are saved when the menu is closed.
+func (g *Game) MenuSettings() int {
+	sel := 0
+	for {
+		s := g.settings
+		options := []string{
+			fmt.Sprintf("Speed: %v%%", s.Speed),
+			fmt.Sprintf("Bits: %v", s.NumBits),
+			fmt.Sprintf("Map Size: %v", s.MapSize),
+			fmt.Sprintf("Camera: %v", s.Camera),
+			fmt.Sprintf("Theme: %v", s.Theme),
+			fmt.Sprintf("Bell: %v", onOff(s.Bell)),
+			fmt.Sprintf("Walls: %v", s.walls()),
+			fmt.Sprintf("Co-op Pass Through: %v", onOff(s.PassThrough)),
+			"Key Bindings",
+		}
+
+		g.screen.Clear()
+		g.gview.Clear()
+		renderSnakeLogo(g, g.viewWidth/2, g.viewHeight/2)
+		renderGoLogo(g, g.viewWidth/2, g.viewHeight/2)
+		g.sbar.SetCenter(settingsControls, g.DefStyle)
+		g.sbar.Draw()
+		m := NewMainMenu(options, g.viewWidth, g.viewHeight, g.DefStyle, g.SelStyle, sel)
+		renderMenu(g, m, g.DefStyle)
+
+		ev := g.screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventKey:
+			switch {
+			case ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyExit:
+				WriteSettings(g.settings, g.configFile)
+				g.sbar.SetCenter(g.controls, g.DefStyle)
+				return MenuMain
+			case ev.Key() == tcell.KeyUp:
+				if sel > 0 {
+					sel--
+				}
+			case ev.Key() == tcell.KeyDown:
+				if sel < len(options)-1 {
+					sel++
+				}
+			case ev.Key() == tcell.KeyLeft:
+				g.changeSetting(sel, -1)
+			case ev.Key() == tcell.KeyRight || ev.Key() == tcell.KeyEnter:
+				g.changeSetting(sel, 1)
+			}
+		case *tcell.EventResize:
+			g.layout(true)
+			g.screen.Sync()
+		}
+	}
+}
+
+// changeSetting moves a setting d steps through its values.
+func (g *Game) changeSetting(i, d int) {
+	s := g.settings
+	switch i {
+	case 0:
+		s.Speed = speedOptions[step(indexInt(speedOptions, s.Speed), d, len(speedOptions))]
+	case 1:
+		s.NumBits = bitOptions[step(indexInt(bitOptions, s.NumBits), d, len(bitOptions))]
+	case 2:
+		s.MapSize = mapSizeOptions[step(indexStr(mapSizeOptions, s.MapSize), d, len(mapSizeOptions))]
+		g.applySettings()
+		g.layout(true)
+	case 3:
+		s.Camera = cameraOptions[step(indexStr(cameraOptions, s.Camera), d, len(cameraOptions))]
+		g.applySettings()
+	case 4:
+		s.Theme = style.Themes[step(indexStr(style.Themes, s.Theme), d, len(style.Themes))]
+		g.applySettings()
+		g.screen.SetStyle(g.DefStyle)
+		g.sbar.SetStyle(g.DefStyle)
+	case 5:
+		s.Bell = !s.Bell
+		if s.Bell {
+			g.screen.Beep()
+		}
+	case 6:
+		w := wallOptions[step(indexStr(wallOptions, s.walls()), d, len(wallOptions))]
+		s.Wrap, s.OpenBorder = w == "wrap", w == "open"
+	case 7:
+		s.PassThrough = !s.PassThrough
+	case 8:
+		g.MenuKeys()
+	}
+}
+
+// walls returns what happens at the edge of the map, one of wallOptions.
+func (s *Settings) walls() string {
+	switch {
+	case s.OpenBorder:
+		return "open"
+	case s.Wrap:
+		return "wrap"
+	}
+	return "kill"
+}
+
+// step moves an index d places through n values, wrapping around.
+func step(i, d, n int) int {
+	return ((i+d)%n + n) % n
+}
+
+// indexInt returns the position of v in list or 0 if it is not found.
+func indexInt(list []int, v int) int {
+	for i := range list {
+		if list[i] == v {
+			return i
+		}
+	}
+	return 0
+}
+
+// indexStr returns the position of v in list or 0 if it is not found.
+func indexStr(list []string, v string) int {
+	for i := range list {
+		if strings.EqualFold(list[i], v) {
+			return i
+		}
+	}
+	return 0
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,57 @@
+package game
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadSettingsDefaults(t *testing.T) {
+	partial := DefaultSettings()
+	partial.Speed = 150
+	partial.Wrap = true
+	partial.Keys.Players[0]["up"] = "z"
+
+	tests := []struct {
+		name string
+		data string // Contents of the config file, empty for no file
+		want *Settings
+	}{
+		{"missing", "", DefaultSettings()},
+		{"empty object", "{}", DefaultSettings()},
+		{"invalid", "{speed: 150", DefaultSettings()},
+		{"bad values", `{"speed": 0, "bits": -2}`, DefaultSettings()},
+		{"partial", `{"speed": 150, "wrap": true, "keys": {"players": [{"up": "z"}]}}`, partial},
+	}
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), "config.json")
+		if test.data != "" {
+			if err := ioutil.WriteFile(file, []byte(test.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if s := ReadSettings(file); !reflect.DeepEqual(s, test.want) {
+			t.Errorf("%v: got %+v, want %+v", test.name, s, test.want)
+		}
+	}
+}
+
+func TestSettingsRoundTrip(t *testing.T) {
+	s := DefaultSettings()
+	s.Speed = 75
+	s.NumBits = 20
+	s.MapSize = "auto"
+	s.Camera = "split"
+	s.Bell = true
+	s.OpenBorder = true
+	s.PassThrough = true
+	s.Keys.Game["pause"] = "p"
+	s.Keys.Players[3]["item"] = "Space"
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	WriteSettings(s, file)
+	if got := ReadSettings(file); !reflect.DeepEqual(got, s) {
+		t.Fatalf("got %+v back, want %+v", got, s)
+	}
+}
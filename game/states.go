@@ -19,31 +19,57 @@ const (
 	MenuEdit
 	MenuRemove
 	MenuSettings
+	MenuEditor
+	MenuStage
+	MenuDaily
+	MenuPuzzle
 )
 
-// Game modes
+// Game modes. Modes are saved in the score and replay files so their
+// values must not change. Player1 and Player2 are from before the mode
+// could be picked and are played as Advanced.
 const (
 	Player1 = iota
 	Player2
 	Battle
+	Basic
+	Advanced
+	TimeAttack
+	Survival
+	Coop
+	Campaign
+	Daily
+	Puzzle
+	LightCycle
 )
 
-// Levels
+// Camera modes
 const (
-	Level2 = 20
-	Level3 = 40
-	Level4 = 60
-	Level5 = 80
-	Level6 = 100
+	CameraShared = iota
+	CameraSplit
 )
 
 // Menu item input values
 const (
-	ItemExit  = -1
-	ItemNone  = 0
-	ItemEnter = 1
-	BGMode    = 2
-	FGMode    = 3
+	ItemExit   = -1
+	ItemNone   = 0
+	ItemEnter  = 1
+	BGMode     = 2
+	FGMode     = 3
+	ItemResize = 4
+)
+
+// Level editor tools
+const (
+	ToolWall = iota
+	ToolFloor
+	ToolSpawn
+	ToolBitSpawn
+	ToolBitLine
+	ToolBite
+	ToolItem
+	ToolMovingWall
+	ToolPortal
 )
 
 // Snake editor rotations
@@ -53,16 +79,3 @@ const (
 	Vertical
 	DiagRight
 )
-
-const (
-
-	// Bit states
-	BitStatic = iota
-	BitMoving
-	BitRandom
-)
-
-// Player item states
-const (
-	WallPass = iota
-)
@@ -0,0 +1,15 @@
+package game
+
+import (
+	"time"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Time before the first hazard is added in Survival mode
+const SurvivalTime = 20 * time.Second
+
+// survivalConfig adds the survival rules to an engine config.
+func survivalConfig(config *engine.Config) {
+	config.Survival = SurvivalTime
+}
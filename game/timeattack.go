@@ -0,0 +1,46 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Time Attack mode rules
+const (
+	TimeAttackTime    = 90 * time.Second
+	TimeAttackBonus   = 10 * time.Second
+	TimeAttackPenalty = 15 * time.Second
+)
+
+// timeAttackConfig adds the time attack rules to an engine config.
+func timeAttackConfig(config *engine.Config) {
+	config.RoundTime = TimeAttackTime
+	config.TimeBonus = TimeAttackBonus
+	config.CrashPenalty = TimeAttackPenalty
+}
+
+// renderTimeAttack draws the clock and each player's score, and the
+// results once the time is up.
+func renderTimeAttack(g *Game, s *engine.Snapshot) {
+	renderCenterStr(g.hview, g.viewWidth, g.viewHeight-2, g.SelStyle, "time: "+clock(s.TimeLeft))
+	renderScore(g.hview, s.Players, g.viewWidth, g.viewHeight, g.SelStyle)
+	if s.Break > 0 {
+		renderResults(g, s)
+	}
+}
+
+// renderResults draws the final score of every player, highest first.
+func renderResults(g *Game, s *engine.Snapshot) {
+	players := append(s.Players[:0:0], s.Players...)
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].GetScore() > players[j].GetScore()
+	})
+	rows := []string{"Time's up!", ""}
+	for i, p := range players {
+		rows = append(rows, fmt.Sprintf("%v. %-12.12v%7v", i+1, p.GetName(), p.GetScore()))
+	}
+	renderBox(g, rows)
+}
@@ -1,19 +1,19 @@
 package gamemap
 
-import (
-	"github.com/gdamore/tcell"
-)
-
 // The game map struct
 type GameMap struct {
-	Width    int
-	Height   int
-	X        int
-	Y        int
-	Objects  [][]*Object
-	BitChan  chan bool
-	BiteChan []chan bool
-	WallChan []chan bool
+	Width   int
+	Height  int
+	X       int
+	Y       int
+	Objects [][]*Object
+
+	// The map has no border and everything that leaves one edge comes
+	// back on the opposite edge
+	Wrap bool
+
+	// Each portal on the map and its partner
+	portals map[Point]Point
 }
 
 // Generate an empty map
@@ -24,12 +24,13 @@ func (m *GameMap) InitMap() {
 	}
 }
 
-// Generate walls around perimeter of map
-func (m *GameMap) InitMapBoundary(wallRune, floorRune rune, style tcell.Style) {
+// Generate walls around perimeter of map, or just floor if the map wraps
+func (m *GameMap) InitMapBoundary(wallRune, floorRune rune, style Style) {
+	m.portals = nil
 
 	for x := 0; x < m.Width; x++ {
 		for y := 0; y < m.Height; y++ {
-			if x == 0 || x == m.Width-1 || y == 0 || y == m.Height-1 {
+			if !m.Wrap && (x == 0 || x == m.Width-1 || y == 0 || y == m.Height-1) {
 				m.Objects[x][y] = &Object{x, y, x, y, wallRune, style, true}
 			} else {
 				m.Objects[x][y] = &Object{x, y, x, y, floorRune, style, false}
@@ -37,3 +38,45 @@ func (m *GameMap) InitMapBoundary(wallRune, floorRune rune, style tcell.Style) {
 		}
 	}
 }
+
+// WrapPos brings a position that is off the map back on from the opposite
+// edge if the map wraps. Other positions are returned unchanged.
+func (m *GameMap) WrapPos(x, y int) (int, int) {
+	if !m.Wrap {
+		return x, y
+	}
+	return mod(x, m.Width), mod(y, m.Height)
+}
+
+// WrapMove changes a move of dx, dy from x, y so that it ends on the map
+// if the map wraps.
+func (m *GameMap) WrapMove(x, y, dx, dy int) (int, int) {
+	nx, ny := m.WrapPos(x+dx, y+dy)
+	return nx - x, ny - y
+}
+
+// Dist returns how far apart two positions are across and down, taking the
+// shorter way around if the map wraps.
+func (m *GameMap) Dist(x1, y1, x2, y2 int) (int, int) {
+	dx, dy := abs(x1-x2), abs(y1-y2)
+	if m.Wrap {
+		if m.Width-dx < dx {
+			dx = m.Width - dx
+		}
+		if m.Height-dy < dy {
+			dy = m.Height - dy
+		}
+	}
+	return dx, dy
+}
+
+func mod(a, n int) int {
+	return (a%n + n) % n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
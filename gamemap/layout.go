@@ -0,0 +1,106 @@
+package gamemap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layout tiles
+const (
+	TileWall  = '#'
+	TileFloor = '.'
+	TileSpawn = 'S'
+	TileBit   = 'B'
+	TileItem  = 'I'
+)
+
+// Portal tiles. The two tiles with the same digit are a pair of portals.
+const TilePortals = "123456789"
+
+// Point is a position on a GameMap.
+type Point struct {
+	X, Y int
+}
+
+// Layout holds the special positions found in a text map layout.
+type Layout struct {
+	Spawns []Point // Player spawn positions
+	Bits   []Point // Positions that bits spawn on
+	Items  []Point // Positions of starting items
+
+	// Positions of each pair of portals in the order of their digits. A
+	// portal without a partner is left as floor.
+	Portals [][2]Point
+}
+
+// CheckLayout makes sure a text map layout fits on a map of the given size,
+// only uses known tiles and has no more than two of each portal.
+func CheckLayout(rows []string, width, height int) error {
+	portals := make(map[rune]int)
+	if len(rows) > height {
+		return fmt.Errorf("layout is %v rows high but the map is only %v", len(rows), height)
+	}
+	for y, row := range rows {
+		r := []rune(row)
+		if len(r) > width {
+			return fmt.Errorf("layout row %v is %v wide but the map is only %v", y+1, len(r), width)
+		}
+		for x, c := range r {
+			switch c {
+			case TileWall, TileFloor, TileSpawn, TileBit, TileItem, ' ':
+			default:
+				if !strings.ContainsRune(TilePortals, c) {
+					return fmt.Errorf("layout row %v column %v has unknown tile %q", y+1, x+1, c)
+				}
+				portals[c]++
+				if portals[c] > 2 {
+					return fmt.Errorf("layout has more than two %q portals", c)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// InitMapLayout places a text map layout in the middle of the map. Walls
+// become blocked objects and every other tile becomes floor. The positions
+// of spawn, bit and item tiles are returned in a Layout.
+func (m *GameMap) InitMapLayout(rows []string, wallRune, floorRune rune, style Style) (*Layout, error) {
+	if err := CheckLayout(rows, m.Width, m.Height); err != nil {
+		return nil, err
+	}
+
+	var l Layout
+	portals := make(map[rune][]Point)
+	oy := (m.Height - len(rows)) / 2
+	for y, row := range rows {
+		r := []rune(row)
+		ox := (m.Width - len(r)) / 2
+		for x, c := range r {
+			mx, my := ox+x, oy+y
+			if c == TileWall {
+				m.Objects[mx][my] = NewObject(mx, my, wallRune, style, true)
+				continue
+			}
+			m.Objects[mx][my] = NewObject(mx, my, floorRune, style, false)
+			switch c {
+			case TileSpawn:
+				l.Spawns = append(l.Spawns, Point{mx, my})
+			case TileBit:
+				l.Bits = append(l.Bits, Point{mx, my})
+			case TileItem:
+				l.Items = append(l.Items, Point{mx, my})
+			default:
+				if strings.ContainsRune(TilePortals, c) {
+					portals[c] = append(portals[c], Point{mx, my})
+				}
+			}
+		}
+	}
+	for _, c := range TilePortals {
+		if p := portals[c]; len(p) == 2 {
+			l.Portals = append(l.Portals, [2]Point{p[0], p[1]})
+		}
+	}
+	return &l, nil
+}
@@ -1,19 +1,15 @@
 package gamemap
 
-import (
-	"github.com/gdamore/tcell"
-)
-
 // Object struct
 type Object struct {
 	x, y, ox, oy int
 	char         rune
-	style        tcell.Style
+	style        Style
 	blocked      bool
 }
 
 // Create new Object
-func NewObject(x, y int, char rune, style tcell.Style, blocked bool) *Object {
+func NewObject(x, y int, char rune, style Style, blocked bool) *Object {
 	o := Object{
 		x,
 		y,
@@ -66,11 +62,11 @@ func (o *Object) SetChar(char rune) {
 	o.char = char
 }
 
-func (o *Object) GetStyle() tcell.Style {
+func (o *Object) GetStyle() Style {
 	return o.style
 }
 
-func (o *Object) SetStyle(style tcell.Style) {
+func (o *Object) SetStyle(style Style) {
 	o.style = style
 }
 
@@ -0,0 +1,31 @@
+package gamemap
+
+// AddPortal places a pair of portals at a and b. A portal is floor, but a
+// snake that moves onto one of them comes out of the other.
+func (m *GameMap) AddPortal(a, b Point, char rune, style Style) {
+	if m.portals == nil {
+		m.portals = make(map[Point]Point)
+	}
+	m.portals[a], m.portals[b] = b, a
+	m.Objects[a.X][a.Y] = NewObject(a.X, a.Y, char, style, false)
+	m.Objects[b.X][b.Y] = NewObject(b.X, b.Y, char, style, false)
+}
+
+// RemovePortal turns the portal at a and its partner back into floor.
+func (m *GameMap) RemovePortal(a Point, floorRune rune, style Style) {
+	b, ok := m.portals[a]
+	if !ok {
+		return
+	}
+	delete(m.portals, a)
+	delete(m.portals, b)
+	m.Objects[a.X][a.Y] = NewObject(a.X, a.Y, floorRune, style, false)
+	m.Objects[b.X][b.Y] = NewObject(b.X, b.Y, floorRune, style, false)
+}
+
+// Portal returns the partner of the portal at x, y and whether there is a
+// portal there.
+func (m *GameMap) Portal(x, y int) (Point, bool) {
+	p, ok := m.portals[Point{x, y}]
+	return p, ok
+}
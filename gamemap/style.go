@@ -0,0 +1,42 @@
+package gamemap
+
+// Style says how an object is drawn without tying the map to a terminal.
+// Objects use one of the theme's styles or name their own colors, and the
+// frontend picks the real colors for each Style.
+type Style struct {
+	Kind int    // One of the style kinds
+	N    int    // Pair of portals for StylePortal
+	FG   string // Foreground color name for StyleColor
+	BG   string // Background color name for StyleColor
+}
+
+// Style kinds
+const (
+	StyleDefault = iota
+	StyleSelect
+	StyleBit
+	StyleBite
+	StyleBiteExploded
+	StylePortal
+	StyleColor
+)
+
+// Styles taken from the theme
+var (
+	DefStyle          = Style{Kind: StyleDefault}
+	SelStyle          = Style{Kind: StyleSelect}
+	BitStyle          = Style{Kind: StyleBit}
+	BiteStyle         = Style{Kind: StyleBite}
+	BiteExplodedStyle = Style{Kind: StyleBiteExploded}
+)
+
+// PortalStyle returns the style of the nth pair of portals.
+func PortalStyle(n int) Style {
+	return Style{Kind: StylePortal, N: n}
+}
+
+// ColorStyle returns a style with the named foreground and background
+// colors.
+func ColorStyle(fg, bg string) Style {
+	return Style{Kind: StyleColor, FG: fg, BG: bg}
+}
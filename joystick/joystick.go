@@ -0,0 +1,213 @@
+// Package joystick reads direction and button input from Linux joystick
+// (/dev/input/js*) and evdev (/dev/input/event*) game controllers.
+package joystick
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+const (
+	// Joystick API events
+	jsEventSize = 8
+	jsButton    = 0x01
+	jsAxis      = 0x02
+	jsInit      = 0x80
+
+	// Evdev event types and codes
+	evKey         = 0x01
+	evAbs         = 0x03
+	absX          = 0x00
+	absY          = 0x01
+	absHat0X      = 0x10
+	absHat0Y      = 0x11
+	btnFirst      = 0x120 // First joystick button
+	btnLast       = 0x13f // Last gamepad button
+	btnDpadUp     = 0x220
+	btnDpadDown   = 0x221
+	btnDpadLeft   = 0x222
+	btnDpadRight  = 0x223
+	evdevDataSize = 8 // Type, code and value after the timestamp
+
+	// How far a stick has to be pushed to count as a direction
+	threshold = 0.5
+
+	// No action
+	none = -1
+)
+
+// Joystick API axis numbers of the left stick and the D-pad
+var jsAxes = map[uint8]uint16{0: absX, 1: absY, 6: absHat0X, 7: absHat0Y}
+
+// AxisRange is the lowest and highest value an evdev axis reports.
+type AxisRange struct {
+	Min, Max int32
+}
+
+// Device reads actions from a single controller. Sticks and D-pads produce
+// the engine's direction actions and buttons produce ActionItem.
+type Device struct {
+	Name     string
+	r        io.Reader
+	evdev    bool
+	timeSize int                  // Size of an evdev timestamp
+	ranges   map[uint16]AxisRange // Ranges of the evdev axes
+	axes     map[uint16]float64   // Axis positions from -1 to 1
+	dirs     map[uint16]int       // Last direction of each stick
+}
+
+// NewJS creates a Device that reads joystick API events from r.
+func NewJS(name string, r io.Reader) *Device {
+	d := Device{
+		Name: name,
+		r:    r,
+		axes: make(map[uint16]float64),
+		dirs: make(map[uint16]int),
+	}
+	return &d
+}
+
+// NewEvdev creates a Device that reads evdev events from r. Events start
+// with a timestamp of timeSize bytes. Axes missing from ranges are assumed
+// to report signed 16 bit values, or -1 to 1 for hats.
+func NewEvdev(name string, r io.Reader, timeSize int, ranges map[uint16]AxisRange) *Device {
+	d := NewJS(name, r)
+	d.evdev = true
+	d.timeSize = timeSize
+	d.ranges = ranges
+	return d
+}
+
+// Read blocks until the controller produces an action and returns it.
+func (d *Device) Read() (int, error) {
+	for {
+		var a int
+		var err error
+		if d.evdev {
+			a, err = d.readEvdev()
+		} else {
+			a, err = d.readJS()
+		}
+		if err != nil {
+			return none, err
+		}
+		if a != none {
+			return a, nil
+		}
+	}
+}
+
+// Close closes the device if it can be closed.
+func (d *Device) Close() error {
+	if c, ok := d.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// readJS reads one joystick API event and returns its action, if any.
+func (d *Device) readJS() (int, error) {
+	buf := make([]byte, jsEventSize)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return none, err
+	}
+	value := int16(binary.LittleEndian.Uint16(buf[4:6]))
+	typ, number := buf[6], buf[7]
+
+	// Ignore the events describing the starting state
+	if typ&jsInit != 0 {
+		return none, nil
+	}
+	switch typ {
+	case jsButton:
+		if value == 1 {
+			return engine.ActionItem, nil
+		}
+	case jsAxis:
+		if code, ok := jsAxes[number]; ok {
+			return d.move(code, float64(value)/math.MaxInt16), nil
+		}
+	}
+	return none, nil
+}
+
+// readEvdev reads one evdev event and returns its action, if any.
+func (d *Device) readEvdev() (int, error) {
+	buf := make([]byte, d.timeSize+evdevDataSize)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return none, err
+	}
+	buf = buf[d.timeSize:]
+	typ := binary.LittleEndian.Uint16(buf[0:2])
+	code := binary.LittleEndian.Uint16(buf[2:4])
+	value := int32(binary.LittleEndian.Uint32(buf[4:8]))
+
+	switch typ {
+	case evKey:
+		if value != 1 {
+			return none, nil
+		}
+		switch {
+		case code == btnDpadUp:
+			return engine.ActionUp, nil
+		case code == btnDpadDown:
+			return engine.ActionDown, nil
+		case code == btnDpadLeft:
+			return engine.ActionLeft, nil
+		case code == btnDpadRight:
+			return engine.ActionRight, nil
+		case code >= btnFirst && code <= btnLast:
+			return engine.ActionItem, nil
+		}
+	case evAbs:
+		switch code {
+		case absX, absY, absHat0X, absHat0Y:
+			return d.move(code, d.normalize(code, value)), nil
+		}
+	}
+	return none, nil
+}
+
+// normalize converts an evdev axis value to a position from -1 to 1.
+func (d *Device) normalize(code uint16, value int32) float64 {
+	r, ok := d.ranges[code]
+	if !ok || r.Max <= r.Min {
+		r = AxisRange{math.MinInt16, math.MaxInt16}
+		if code == absHat0X || code == absHat0Y {
+			r = AxisRange{-1, 1}
+		}
+	}
+	return 2*float64(value-r.Min)/float64(r.Max-r.Min) - 1
+}
+
+// move records an axis position and returns a direction action when its
+// stick is pushed in a new direction. A stick has to return to the middle
+// before it can send the same direction again.
+func (d *Device) move(code uint16, pos float64) int {
+	d.axes[code] = pos
+	stick := code &^ 1
+	x, y := d.axes[stick], d.axes[stick|1]
+
+	dir := none
+	switch {
+	case math.Abs(x) < threshold && math.Abs(y) < threshold:
+	case math.Abs(x) >= math.Abs(y) && x < 0:
+		dir = engine.ActionLeft
+	case math.Abs(x) >= math.Abs(y):
+		dir = engine.ActionRight
+	case y < 0:
+		dir = engine.ActionUp
+	default:
+		dir = engine.ActionDown
+	}
+
+	last, ok := d.dirs[stick]
+	d.dirs[stick] = dir
+	if dir == none || (ok && dir == last) {
+		return none
+	}
+	return dir
+}
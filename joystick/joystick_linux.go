@@ -0,0 +1,49 @@
+package joystick
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// Open opens a joystick API device such as /dev/input/js0 or an evdev
+// device such as /dev/input/event5.
+func Open(path string) (*Device, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(filepath.Base(path), "js") {
+		return NewJS(path, f), nil
+	}
+
+	// Ask the device for the range of each axis
+	ranges := make(map[uint16]AxisRange)
+	for _, code := range []uint16{absX, absY, absHat0X, absHat0Y} {
+		var info [6]int32 // value, minimum, maximum, fuzz, flat, resolution
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), eviocgabs(code), uintptr(unsafe.Pointer(&info)))
+		if errno == 0 {
+			ranges[code] = AxisRange{info[1], info[2]}
+		}
+	}
+	return NewEvdev(path, f, int(unsafe.Sizeof(syscall.Timeval{})), ranges), nil
+}
+
+// Scan returns the paths of the connected controllers. Joystick API devices
+// are used if there are any, otherwise the evdev joystick devices are used.
+func Scan() []string {
+	paths, _ := filepath.Glob("/dev/input/js*")
+	if len(paths) > 0 {
+		return paths
+	}
+	paths, _ = filepath.Glob("/dev/input/by-id/*-event-joystick")
+	return paths
+}
+
+// eviocgabs returns the EVIOCGABS ioctl request for an axis.
+func eviocgabs(code uint16) uintptr {
+	const size = 6 * 4
+	return uintptr(2<<30 | size<<16 | 'E'<<8 | (0x40 + int(code)))
+}
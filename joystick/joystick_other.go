@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package joystick
+
+import "errors"
+
+// Open is only supported on Linux.
+func Open(path string) (*Device, error) {
+	return nil, errors.New("controllers are only supported on Linux")
+}
+
+// Scan returns no controllers outside of Linux.
+func Scan() []string {
+	return nil
+}
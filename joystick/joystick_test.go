@@ -0,0 +1,139 @@
+package joystick
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stjiub/gosnake/engine"
+)
+
+// Size of the evdev timestamps used by the tests
+const timeSize = 16
+
+// jsEvent builds a joystick API event.
+func jsEvent(typ, number uint8, value int16) []byte {
+	buf := make([]byte, jsEventSize)
+	binary.LittleEndian.PutUint16(buf[4:6], uint16(value))
+	buf[6], buf[7] = typ, number
+	return buf
+}
+
+// evEvent builds an evdev event with an empty timestamp.
+func evEvent(typ, code uint16, value int32) []byte {
+	buf := make([]byte, timeSize+evdevDataSize)
+	binary.LittleEndian.PutUint16(buf[timeSize:], typ)
+	binary.LittleEndian.PutUint16(buf[timeSize+2:], code)
+	binary.LittleEndian.PutUint32(buf[timeSize+4:], uint32(value))
+	return buf
+}
+
+// actions reads every action from d until its events run out.
+func actions(t *testing.T, d *Device) []int {
+	var got []int
+	for {
+		a, err := d.Read()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, a)
+	}
+}
+
+// js returns the actions read from a joystick API device sending events.
+func js(t *testing.T, events ...[]byte) []int {
+	return actions(t, NewJS("js", bytes.NewReader(bytes.Join(events, nil))))
+}
+
+// evdev returns the actions read from an evdev device sending events.
+func evdev(t *testing.T, ranges map[uint16]AxisRange, events ...[]byte) []int {
+	return actions(t, NewEvdev("event", bytes.NewReader(bytes.Join(events, nil)), timeSize, ranges))
+}
+
+// check fails the test if the actions read are not want.
+func check(t *testing.T, got []int, want ...int) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got actions %v, want %v", got, want)
+	}
+}
+
+func TestJSDpad(t *testing.T) {
+	check(t, js(t,
+		jsEvent(jsAxis, 7, -32767), jsEvent(jsAxis, 7, 0),
+		jsEvent(jsAxis, 7, 32767), jsEvent(jsAxis, 7, 0),
+		jsEvent(jsAxis, 6, -32767), jsEvent(jsAxis, 6, 0),
+		jsEvent(jsAxis, 6, 32767), jsEvent(jsAxis, 6, 0),
+	), engine.ActionUp, engine.ActionDown, engine.ActionLeft, engine.ActionRight)
+}
+
+func TestJSStick(t *testing.T) {
+	check(t, js(t,
+		// Small pushes are ignored
+		jsEvent(jsAxis, 0, 10000),
+		jsEvent(jsAxis, 0, 20000),
+		// Holding the same direction only sends it once
+		jsEvent(jsAxis, 0, 30000),
+		// The stronger axis wins
+		jsEvent(jsAxis, 1, 32000),
+		// Back to the middle, then the same direction again
+		jsEvent(jsAxis, 0, 0), jsEvent(jsAxis, 1, 0),
+		jsEvent(jsAxis, 1, 32000),
+		// Axes that aren't a stick or D-pad are ignored
+		jsEvent(jsAxis, 3, -32767),
+	), engine.ActionRight, engine.ActionDown, engine.ActionDown)
+}
+
+func TestJSInitAndButtons(t *testing.T) {
+	check(t, js(t,
+		jsEvent(jsInit|jsAxis, 0, -32767),
+		jsEvent(jsInit|jsButton, 0, 1),
+		jsEvent(jsButton, 2, 1),
+		jsEvent(jsButton, 2, 0),
+	), engine.ActionItem)
+}
+
+func TestEvdevDpad(t *testing.T) {
+	check(t, evdev(t, nil,
+		evEvent(evKey, btnDpadUp, 1), evEvent(evKey, btnDpadUp, 0),
+		evEvent(evKey, btnDpadDown, 1), evEvent(evKey, btnDpadDown, 0),
+		evEvent(evKey, btnDpadLeft, 1),
+		evEvent(evKey, btnDpadRight, 1),
+		// Hats report -1 to 1 and go back to 0 when released
+		evEvent(evAbs, absHat0Y, -1), evEvent(evAbs, absHat0Y, 0),
+		evEvent(evAbs, absHat0X, 1), evEvent(evAbs, absHat0X, 0),
+	), engine.ActionUp, engine.ActionDown, engine.ActionLeft, engine.ActionRight, engine.ActionUp, engine.ActionRight)
+}
+
+func TestEvdevStick(t *testing.T) {
+	// A stick reporting 0 to 255 with the middle at 128
+	ranges := map[uint16]AxisRange{absX: {0, 255}, absY: {0, 255}}
+	check(t, evdev(t, ranges,
+		evEvent(evAbs, absX, 128),
+		evEvent(evAbs, absX, 180),
+		evEvent(evAbs, absX, 10),
+		evEvent(evAbs, absX, 0),
+		evEvent(evAbs, absX, 128),
+		evEvent(evAbs, absY, 250),
+		evEvent(evAbs, absY, 128),
+		evEvent(evAbs, absY, 250),
+	), engine.ActionLeft, engine.ActionDown, engine.ActionDown)
+}
+
+func TestEvdevButtons(t *testing.T) {
+	check(t, evdev(t, nil,
+		evEvent(evKey, btnFirst, 1),
+		evEvent(evKey, btnFirst, 0),
+		// Held buttons repeat with a value of 2
+		evEvent(evKey, btnLast, 1), evEvent(evKey, btnLast, 2),
+		// Keys outside the gamepad buttons are ignored
+		evEvent(evKey, 0x1e, 1),
+		// Sync events are ignored
+		evEvent(0, 0, 0),
+	), engine.ActionItem, engine.ActionItem)
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"flag"
 	"log"
-	"math/rand"
 	"os"
 	"time"
 
@@ -12,26 +11,36 @@ import (
 )
 
 const (
-	logFile   = "log.txt"
-	proFile   = "profiles.json"
-	scoreFile = "hs.json"
+	logFile     = "log.txt"
+	configFile  = "config.json"
+	proFile     = "profiles.json"
+	scoreFile   = "hs.json"
+	levelDir    = "levels"
+	campaignDir = "campaign"
+	puzzleDir   = "puzzles"
+	replayDir   = "replays"
 )
 
 var (
 	verbose = flag.Bool("verbose", false, "print info level logs to stdout")
+	seed    = flag.Int64("seed", 0, "seed for map generation, 0 picks a new seed every game")
+	replay  = flag.String("replay", "", "play back a recorded replay file")
+	mapSize = flag.String("mapsize", "", "map size as WIDTHxHEIGHT, or auto to fit the terminal (overrides the settings)")
+	camera  = flag.String("camera", "", "camera for 2 players, shared or split (overrides the settings)")
 
 	// Keep track of previous game values
 	lastGameState  int = game.Play
 	lastNumPlayers int
+	lastMode       int
+	lastStage      int
+	lastPack       int
+	lastPuzzle     int
 	curProfiles    []*game.Profile
 )
 
 func main() {
 	flag.Parse()
 
-	// Set rand seed
-	rand.Seed(time.Now().UnixNano())
-
 	// Set logging
 	lf, err := os.OpenFile(logFile, os.O_RDWR|os.O_CREATE|os.O_WRONLY, 0660)
 	if err != nil {
@@ -44,10 +53,51 @@ func main() {
 	defer logger.Init("Error log", *verbose, true, lf).Close()
 	logger.SetFlags(log.LstdFlags)
 
+	// Load settings
+	settings := game.ReadSettings(configFile)
+
+	// Start reading any connected controllers
+	pads := game.OpenPads()
+
+	// Play back a replay instead of starting the game
+	if *replay != "" {
+		g := game.NewGame(0, nil, settings, configFile, scoreFile, proFile, levelDir, campaignDir, puzzleDir, replayDir, 0)
+		err := g.InitScreen()
+		if err != nil {
+			logger.Fatalf("Error initializing screen: %v", err)
+		}
+		err = g.PlayReplay(*replay)
+		if err != nil {
+			g.Return()
+			logger.Fatalf("Error playing replay: %v", err)
+		}
+		g.Quit()
+	}
+
 	// Game loop
 	for {
+		// Use the chosen seed or pick a new one so every game is different
+		gameSeed := *seed
+		if gameSeed == 0 {
+			gameSeed = time.Now().UnixNano()
+		}
+
 		// Create game
-		g := game.NewGame(lastNumPlayers, curProfiles, scoreFile, proFile)
+		g := game.NewGame(lastNumPlayers, curProfiles, settings, configFile, scoreFile, proFile, levelDir, campaignDir, puzzleDir, replayDir, gameSeed)
+		g.SetPads(pads)
+		g.SetMode(lastMode)
+		g.SetStage(lastStage)
+		g.SetPuzzle(lastPack, lastPuzzle)
+		if *mapSize != "" {
+			if err := g.SetMapSize(*mapSize); err != nil {
+				logger.Fatalf("Error setting map size: %v", err)
+			}
+		}
+		if *camera != "" {
+			if err := g.SetCamera(*camera); err != nil {
+				logger.Fatalf("Error setting camera: %v", err)
+			}
+		}
 
 		// Initialize screen
 		err := g.InitScreen()
@@ -65,13 +115,9 @@ func main() {
 
 		if g.GetState() == game.Play {
 			// Setup a game
-			err := g.InitMap()
-			if err != nil {
-				logger.Fatalf("Error initializing map: %v", err)
-			}
-			err = g.InitPlayers()
+			err := g.InitEngine()
 			if err != nil {
-				logger.Fatalf("Error initializing players: %v", err)
+				logger.Fatalf("Error initializing game: %v", err)
 			}
 
 			// Run the game
@@ -89,6 +135,9 @@ func main() {
 		// Save game values
 		lastGameState = g.GetState()
 		lastNumPlayers = g.GetNumPlayers()
+		lastMode = g.GetMode()
+		lastStage = g.GetStage()
+		lastPack, lastPuzzle = g.GetPuzzle()
 		curProfiles = g.GetCurProfiles()
 	}
 }
@@ -2,6 +2,7 @@ package style
 
 import (
 	"github.com/gdamore/tcell"
+	"github.com/stjiub/gosnake/gamemap"
 )
 
 const (
@@ -29,6 +30,9 @@ const (
 	SelFGStyle = Aqua
 )
 
+// Themes that can be picked in the settings menu
+var Themes = []string{"default", "light", "green", "mono"}
+
 type Style struct {
 	DefStyle          tcell.Style
 	SelStyle          tcell.Style
@@ -36,6 +40,7 @@ type Style struct {
 	BitStyle          tcell.Style
 	BiteStyle         tcell.Style
 	BiteExplodedStyle tcell.Style
+	PortalStyles      []tcell.Style // Each pair of portals uses the next style
 	DefBGColor        tcell.Color
 	DefFGColor        tcell.Color
 	DefSelColor       tcell.Color
@@ -48,11 +53,50 @@ func (s *Style) SetDefaultStyle() {
 	s.BitStyle = GetStyle(Black, White)
 	s.BiteStyle = GetStyle(Black, Fuchsia)
 	s.BiteExplodedStyle = GetStyle(Black, Red)
+	s.PortalStyles = []tcell.Style{GetStyle(Black, Aqua), GetStyle(Black, Yellow), GetStyle(Black, Lime), GetStyle(Black, Fuchsia)}
 	s.DefBGColor = Black
 	s.DefFGColor = Silver
 	s.DefSelColor = Aqua
 }
 
+// SetTheme changes the colors to one of the Themes. Unknown themes use the
+// default colors.
+func (s *Style) SetTheme(theme string) {
+	s.SetDefaultStyle()
+	switch theme {
+	case "light":
+		s.DefStyle = GetStyle(White, Black)
+		s.SelStyle = GetStyle(White, Blue)
+		s.SelStyleBG = GetStyle(Blue, White)
+		s.BitStyle = GetStyle(White, Black)
+		s.BiteStyle = GetStyle(White, Purple)
+		s.BiteExplodedStyle = GetStyle(White, Red)
+		s.PortalStyles = []tcell.Style{GetStyle(White, Blue), GetStyle(White, Maroon), GetStyle(White, Green), GetStyle(White, Purple)}
+		s.DefBGColor = White
+		s.DefFGColor = Black
+		s.DefSelColor = Blue
+	case "green":
+		s.DefStyle = GetStyle(Black, Green)
+		s.SelStyle = GetStyle(Black, Lime)
+		s.SelStyleBG = GetStyle(Lime, Black)
+		s.BitStyle = GetStyle(Black, Lime)
+		s.BiteStyle = GetStyle(Black, Yellow)
+		s.BiteExplodedStyle = GetStyle(Black, Olive)
+		s.PortalStyles = []tcell.Style{GetStyle(Black, Lime), GetStyle(Black, Yellow), GetStyle(Black, Teal), GetStyle(Black, Olive)}
+		s.DefFGColor = Green
+		s.DefSelColor = Lime
+	case "mono":
+		s.DefStyle = GetStyle(Black, Silver)
+		s.SelStyle = GetStyle(Black, White)
+		s.SelStyleBG = GetStyle(Silver, Black)
+		s.BitStyle = GetStyle(Black, White)
+		s.BiteStyle = GetStyle(Black, White)
+		s.BiteExplodedStyle = GetStyle(Black, Gray)
+		s.PortalStyles = []tcell.Style{GetStyle(Black, White), GetStyle(Black, Silver), GetStyle(Black, Gray)}
+		s.DefSelColor = White
+	}
+}
+
 func (s *Style) SetNewStyle(defStyle, selStyle, selStyleBG, bitStyle, biteStyle, biteExplodedStyle tcell.Style, defBGColor, defFGColor, defSelColor tcell.Color, playerColors []tcell.Style) {
 	s.DefStyle = defStyle
 	s.SelStyle = selStyle
@@ -77,3 +121,24 @@ func StringToStyle(fg, bg string) tcell.Style {
 	style := GetStyle(bgColor, fgColor)
 	return style
 }
+
+// Get returns the colors a map object with the given style is drawn in.
+func (s *Style) Get(o gamemap.Style) tcell.Style {
+	switch o.Kind {
+	case gamemap.StyleSelect:
+		return s.SelStyle
+	case gamemap.StyleBit:
+		return s.BitStyle
+	case gamemap.StyleBite:
+		return s.BiteStyle
+	case gamemap.StyleBiteExploded:
+		return s.BiteExplodedStyle
+	case gamemap.StylePortal:
+		if len(s.PortalStyles) > 0 {
+			return s.PortalStyles[o.N%len(s.PortalStyles)]
+		}
+	case gamemap.StyleColor:
+		return StringToStyle(o.FG, o.BG)
+	}
+	return s.DefStyle
+}